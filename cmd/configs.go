@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+	"github.com/spf13/cobra"
+)
+
+// configRun is a config file processed by one invocation, with everything loaded for it
+type configRun struct {
+	path   string
+	dir    string // directory of the config file, used for relative path calculations
+	cfg    config.Config
+	vars   map[string]any
+	logger *slog.Logger
+	err    error // the first error loading, validating or processing the config
+}
+
+// configPathsFromFlags returns the config files given by the --config flags in order, expanding
+// globs such as teams/*/replicator.yaml. A file matched by several flags is only returned once.
+func configPathsFromFlags(cmd *cobra.Command) ([]string, error) {
+	patterns, err := cmd.Flags().GetStringArray("config")
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("at least one --config is required")
+	}
+
+	var paths []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("--config %s is not a valid glob: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("--config %s matches no files", pattern)
+			}
+			slices.Sort(matches)
+		}
+		for _, match := range matches {
+			key := match
+			if abs, err := filepath.Abs(match); err == nil {
+				key = abs
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			paths = append(paths, match)
+		}
+	}
+	return paths, nil
+}
+
+// loadConfigRun loads the variables and the config for the config file at configFilePath
+func loadConfigRun(cmd *cobra.Command, configFilePath string) (map[string]any, config.Config, string, error) {
+	vars, err := loadVariablesFromFlags(cmd, configFilePath)
+	if err != nil {
+		return nil, config.Config{}, "", err
+	}
+	cfg, configDir, err := loadConfigFromFlags(cmd, configFilePath, vars)
+	if err != nil {
+		return nil, config.Config{}, "", err
+	}
+	return vars, cfg, configDir, nil
+}
+
+// configLogger returns the logger for a config. When several configs are processed, their log
+// lines are told apart by the config attribute.
+func configLogger(configFilePath string, configCount int) *slog.Logger {
+	if configCount == 1 {
+		return slog.Default()
+	}
+	return slog.Default().With("config", configFilePath)
+}
+
+// validateOutputDirectoriesAcrossConfigs checks that no two loaded configs write to the same
+// output directory, and marks the configs that do as failed so that the other configs are still
+// processed. Units of one config are checked by config.Config.ValidateConfig.
+func validateOutputDirectoriesAcrossConfigs(runs []*configRun) {
+	owners := map[string]*configRun{}
+	for _, run := range runs {
+		if run.err != nil {
+			continue
+		}
+		var dirs []string
+		for _, unit := range run.cfg.Units {
+			outputDir := unit.OutputDirectory
+			if !filepath.IsAbs(outputDir) {
+				outputDir = filepath.Join(run.dir, outputDir)
+			}
+			if abs, err := filepath.Abs(outputDir); err == nil {
+				outputDir = abs
+			}
+			dirs = append(dirs, outputDir)
+		}
+		slices.Sort(dirs)
+
+		for _, dir := range slices.Compact(dirs) {
+			owner, ok := owners[dir]
+			if !ok {
+				owners[dir] = run
+				continue
+			}
+			err := fmt.Errorf("output directory %s is used by both %s and %s", dir, owner.path, run.path)
+			if owner.err == nil {
+				owner.err = err
+			}
+			if run.err == nil {
+				run.err = err
+			}
+		}
+	}
+}
+
+// processConfigs calls process for every config that has been loaded without errors, running up
+// to parallel calls at the same time. Errors are stored in the configs.
+func processConfigs(runs []*configRun, parallel int, process func(run *configRun) error) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, parallel)
+	for _, run := range runs {
+		if run.err != nil {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			run.err = process(run)
+		}()
+	}
+	wg.Wait()
+}
+
+// summarizeConfigRuns reports the outcome of every config and returns an error if any of them
+// failed. A single config returns its error as it is, without a summary.
+func summarizeConfigRuns(runs []*configRun) error {
+	if len(runs) == 1 {
+		return runs[0].err
+	}
+
+	failed := 0
+	for _, run := range runs {
+		if run.err != nil {
+			failed++
+			slog.Error("Config failed", "config", run.path, "error", run.err)
+		}
+	}
+	slog.Info("Processed configs", "total", len(runs), "succeeded", len(runs)-failed, "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configs failed", failed, len(runs))
+	}
+	return nil
+}
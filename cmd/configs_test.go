@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+// executeCommand runs the root command with args and returns what it wrote to stdout
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	c := New()
+	var out bytes.Buffer
+	c.SetOut(&out)
+	c.SetArgs(args)
+	err := c.Execute()
+	return out.String(), err
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestConfigPathsFromFlags(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"teams/b/replicator.yaml", "teams/a/replicator.yaml", "shared.yaml"} {
+		writeFile(t, filepath.Join(dir, file), "units: []\n")
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      []string
+		errorContains string
+	}{
+		{
+			name:     "single file",
+			args:     []string{"--config", "shared.yaml"},
+			expected: []string{"shared.yaml"},
+		},
+		{
+			name:     "repeated flags keep their order",
+			args:     []string{"--config", "teams/b/replicator.yaml", "-c", "shared.yaml"},
+			expected: []string{"teams/b/replicator.yaml", "shared.yaml"},
+		},
+		{
+			name:     "glob is expanded in sorted order",
+			args:     []string{"--config", "teams/*/replicator.yaml"},
+			expected: []string{"teams/a/replicator.yaml", "teams/b/replicator.yaml"},
+		},
+		{
+			name:     "file matched twice is returned once",
+			args:     []string{"--config", "./teams/b/replicator.yaml", "--config", "teams/*/replicator.yaml", "--config", "shared.yaml"},
+			expected: []string{"./teams/b/replicator.yaml", "teams/a/replicator.yaml", "shared.yaml"},
+		},
+		{
+			name:          "glob without matches",
+			args:          []string{"--config", "shared.yaml", "--config", "services/*/replicator.yaml"},
+			errorContains: "--config services/*/replicator.yaml matches no files",
+		},
+		{
+			name:          "invalid glob",
+			args:          []string{"--config", "teams/[a/replicator.yaml"},
+			errorContains: "is not a valid glob",
+		},
+		{
+			name:          "no config",
+			args:          nil,
+			errorContains: "at least one --config is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addConfigFlag(cmd)
+			require.NoError(t, cmd.Flags().Parse(tt.args))
+
+			paths, err := configPathsFromFlags(cmd)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestValidateOutputDirectoriesAcrossConfigs(t *testing.T) {
+	newRun := func(path, dir string, outputDirectories ...string) *configRun {
+		run := &configRun{path: path, dir: dir}
+		for _, outputDirectory := range outputDirectories {
+			run.cfg.Units = append(run.cfg.Units, config.Unit{OutputDirectory: outputDirectory})
+		}
+		return run
+	}
+	loadErr := errors.New("failed to load")
+
+	tests := []struct {
+		name           string
+		runs           func() []*configRun
+		failed         []string
+		errorContains  string
+		untouchedError error
+	}{
+		{
+			name: "distinct output directories",
+			runs: func() []*configRun {
+				return []*configRun{
+					newRun("a.yaml", "teams/a", "output"),
+					newRun("b.yaml", "teams/b", "output"),
+				}
+			},
+		},
+		{
+			name: "same output directory through different relative paths",
+			runs: func() []*configRun {
+				return []*configRun{
+					newRun("a.yaml", "teams/a", "output"),
+					newRun("b.yaml", "teams/b", "../a/output"),
+					newRun("c.yaml", "teams/c", "output"),
+				}
+			},
+			failed:        []string{"a.yaml", "b.yaml"},
+			errorContains: "is used by both a.yaml and b.yaml",
+		},
+		{
+			name: "units of one config sharing a directory are left to ValidateConfig",
+			runs: func() []*configRun {
+				return []*configRun{
+					newRun("a.yaml", "teams/a", "output", "./output"),
+				}
+			},
+		},
+		{
+			name: "configs that failed to load are skipped",
+			runs: func() []*configRun {
+				failed := newRun("a.yaml", "teams/a", "output")
+				failed.err = loadErr
+				return []*configRun{
+					failed,
+					newRun("b.yaml", "teams/b", "../a/output"),
+				}
+			},
+			failed:         []string{"a.yaml"},
+			untouchedError: loadErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := tt.runs()
+			validateOutputDirectoriesAcrossConfigs(runs)
+
+			var failed []string
+			for _, run := range runs {
+				if run.err == nil {
+					continue
+				}
+				failed = append(failed, run.path)
+				if tt.untouchedError != nil {
+					assert.Equal(t, tt.untouchedError, run.err)
+				} else {
+					assert.Contains(t, run.err.Error(), tt.errorContains)
+				}
+			}
+			assert.Equal(t, tt.failed, failed)
+		})
+	}
+}
+
+func TestProcessConfigs(t *testing.T) {
+	processErr := errors.New("failed to process")
+
+	tests := []struct {
+		name     string
+		parallel int
+	}{
+		{name: "sequential", parallel: 1},
+		{name: "parallel", parallel: 2},
+		{name: "more workers than configs", parallel: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := []*configRun{
+				{path: "a.yaml"},
+				{path: "b.yaml"},
+				{path: "c.yaml", err: errors.New("invalid config")},
+				{path: "d.yaml"},
+				{path: "e.yaml"},
+			}
+
+			var mu sync.Mutex
+			var processed []string
+			running, maxRunning := 0, 0
+			processConfigs(runs, tt.parallel, func(run *configRun) error {
+				mu.Lock()
+				processed = append(processed, run.path)
+				running++
+				maxRunning = max(maxRunning, running)
+				mu.Unlock()
+
+				defer func() {
+					mu.Lock()
+					running--
+					mu.Unlock()
+				}()
+				if run.path == "b.yaml" {
+					return processErr
+				}
+				return nil
+			})
+
+			// Configs that failed before processing are skipped
+			expected := []string{"a.yaml", "b.yaml", "d.yaml", "e.yaml"}
+			if tt.parallel == 1 {
+				assert.Equal(t, expected, processed)
+			} else {
+				assert.ElementsMatch(t, expected, processed)
+			}
+			assert.LessOrEqual(t, maxRunning, tt.parallel)
+
+			// Each error stays with its own config
+			assert.NoError(t, runs[0].err)
+			assert.Equal(t, processErr, runs[1].err)
+			assert.EqualError(t, runs[2].err, "invalid config")
+			assert.NoError(t, runs[3].err)
+			assert.NoError(t, runs[4].err)
+		})
+	}
+}
+
+func TestRunMain_OutputDirectoryConflict(t *testing.T) {
+	dir := t.TempDir()
+	unit := func(outputDirectory string) string {
+		return `units:
+  - outputDirectory: ` + outputDirectory + `
+    apiVersion: v1alpha1
+    values:
+      - filename: backup
+        paths:
+          - path: "$.metadata.name"
+            value: backup
+`
+	}
+	writeFile(t, filepath.Join(dir, "a", "config.yaml"), unit("../shared"))
+	writeFile(t, filepath.Join(dir, "b", "config.yaml"), unit("../shared"))
+	writeFile(t, filepath.Join(dir, "c", "config.yaml"), unit("./output"))
+
+	_, err := executeCommand(t, "--config", filepath.Join(dir, "*", "config.yaml"))
+
+	// Only the conflicting configs fail, the other config is still processed
+	require.EqualError(t, err, "2 of 3 configs failed")
+	assert.NoFileExists(t, filepath.Join(dir, "shared", "backup.yaml"))
+	assert.FileExists(t, filepath.Join(dir, "c", "output", "backup.yaml"))
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 
 	"github.com/drumato/cron-workflow-replicator/config"
@@ -24,8 +27,24 @@ func New() *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	c.Flags().StringP("config", "c", "", "Path to config file")
-	c.Flags().String("values", "", "Path to values file for template rendering")
+	addConfigFlag(&c)
+	addTemplateFlags(&c)
+	c.Flags().Bool("stdout", false, "Write generated manifests to stdout as a multi-document YAML stream instead of output directories")
+	c.Flags().Int("parallel", 1, "Number of config files to process in parallel")
+
+	// Add render subcommand
+	renderCmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render generated CronWorkflows to stdout as a multi-document YAML stream",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRender(cmd, args)
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	addConfigFlag(renderCmd)
+	addTemplateFlags(renderCmd)
+	c.AddCommand(renderCmd)
 
 	// Add render-config subcommand
 	renderConfigCmd := &cobra.Command{
@@ -38,7 +57,9 @@ func New() *cobra.Command {
 		SilenceErrors: true,
 	}
 	renderConfigCmd.Flags().StringP("config", "c", "", "Path to config file")
-	renderConfigCmd.Flags().String("values", "", "Path to values file for template rendering")
+	addTemplateFlags(renderConfigCmd)
+	renderConfigCmd.Flags().Bool("show-values", false, "Print the merged values instead of the rendered config")
+	renderConfigCmd.Flags().Bool("show-files", false, "Print the files the rendered config depends on instead of the rendered config")
 	c.AddCommand(renderConfigCmd)
 
 	// Add list subcommand
@@ -51,49 +72,119 @@ func New() *cobra.Command {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
-	listCmd.Flags().StringP("config", "c", "", "Path to config file")
-	listCmd.Flags().String("values", "", "Path to values file for template rendering")
+	addConfigFlag(listCmd)
+	addTemplateFlags(listCmd)
 	c.AddCommand(listCmd)
 
 	return &c
 }
 
+// addConfigFlag adds the repeatable --config flag, see configPathsFromFlags
+func addConfigFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("config", "c", nil, "Path or glob of config files, e.g. teams/*/replicator.yaml (can be repeated)")
+}
+
+// addTemplateFlags adds the flags that provide the variables for templates and control their rendering
+func addTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("strict", false, "Fail on missing template variables instead of rendering \"<no value>\" (default: on for API versions newer than v1alpha1)")
+	cmd.Flags().StringArray("values", nil, "Path to values file for template rendering (can be repeated; later files override earlier ones)")
+	cmd.Flags().StringArray("set", nil, "Set a value on top of the values files, e.g. --set image.tag=v1 (can be repeated)")
+	cmd.Flags().StringArray("set-string", nil, "Set a value as a string, e.g. --set-string build.id=0123 (can be repeated)")
+	cmd.Flags().StringArray("allow-env", nil, "Environment variable to expose to config templates as .Env and env, e.g. GIT_SHA or CI_* (can be repeated)")
+	cmd.Flags().StringArray("templates", nil, "Directory of *.tpl files or glob of template files to use with include and template, relative to the config file; overrides templatesDir of the config (can be repeated)")
+}
+
 func runMain(cmd *cobra.Command, args []string) (err error) {
-	configFilePath, err := cmd.Flags().GetString("config")
+	toStdout, err := cmd.Flags().GetBool("stdout")
 	if err != nil {
 		return err
 	}
+	if toStdout {
+		return runRender(cmd, args)
+	}
 
-	valuesFilePath, err := cmd.Flags().GetString("values")
+	configFilePaths, err := configPathsFromFlags(cmd)
 	if err != nil {
 		return err
 	}
-
-	// Load and potentially render the config
-	configContent, err := loadConfigWithTemplate(configFilePath, valuesFilePath)
+	parallel, err := cmd.Flags().GetInt("parallel")
 	if err != nil {
 		return err
 	}
-
-	cfg := config.Config{}
-	if err := yaml.Unmarshal(configContent, &cfg); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1, got %d", parallel)
 	}
 
-	// Extract config directory for relative path calculations
-	configDir := filepath.Dir(configFilePath)
+	// Every config is loaded and validated before any of them is processed
+	runs := make([]*configRun, 0, len(configFilePaths))
+	for _, configFilePath := range configFilePaths {
+		run := &configRun{path: configFilePath, logger: configLogger(configFilePath, len(configFilePaths))}
+		runs = append(runs, run)
 
-	// Validate configuration before running
-	if err := cfg.ValidateConfig(configDir); err != nil {
-		slog.Error("Configuration validation failed", "error", err)
-		return err
+		run.vars, run.cfg, run.dir, run.err = loadConfigRun(cmd, configFilePath)
+		if run.err != nil {
+			continue
+		}
+
+		// Validate configuration before running
+		if err := run.cfg.ValidateConfig(run.dir); err != nil {
+			run.logger.Error("Configuration validation failed", "error", err)
+			run.err = err
+		}
 	}
+	validateOutputDirectoriesAcrossConfigs(runs)
 
-	r := runner.New(slog.Default())
-	if err := r.Run(cmd.Context(), cfg, configDir); err != nil {
+	processConfigs(runs, parallel, func(run *configRun) error {
+		opts, err := runnerOptionsFromFlags(cmd, run.vars)
+		if err != nil {
+			return err
+		}
+		r := runner.New(run.logger, opts...)
+		return r.Run(cmd.Context(), run.cfg, run.dir)
+	})
+	return summarizeConfigRuns(runs)
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	configFilePaths, err := configPathsFromFlags(cmd)
+	if err != nil {
 		return err
 	}
 
+	// The manifests of all configs form one stream, in the order of the configs
+	documents := 0
+	for _, configFilePath := range configFilePaths {
+		vars, cfg, configDir, err := loadConfigRun(cmd, configFilePath)
+		if err != nil {
+			return err
+		}
+
+		// Output directories are not used when rendering, so they are not validated either
+		if err := cfg.ValidateConfigWithoutOutput(configDir); err != nil {
+			slog.Error("Configuration validation failed", "config", configFilePath, "error", err)
+			return err
+		}
+
+		opts, err := runnerOptionsFromFlags(cmd, vars)
+		if err != nil {
+			return err
+		}
+		r := runner.New(slog.Default(), opts...)
+		var out bytes.Buffer
+		if err := r.Render(cmd.Context(), cfg, configDir, &out); err != nil {
+			return err
+		}
+		if out.Len() == 0 {
+			continue
+		}
+		if documents > 0 {
+			fmt.Fprint(cmd.OutOrStdout(), "---\n")
+		}
+		if _, err := out.WriteTo(cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		documents++
+	}
 	return nil
 }
 
@@ -103,64 +194,123 @@ func runRenderConfig(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	valuesFilePath, err := cmd.Flags().GetString("values")
+	showValues, err := cmd.Flags().GetBool("show-values")
+	if err != nil {
+		return err
+	}
+	showFiles, err := cmd.Flags().GetBool("show-files")
 	if err != nil {
 		return err
 	}
 
-	// Load and render the config
-	configContent, err := loadConfigWithTemplate(configFilePath, valuesFilePath)
+	vars, err := loadVariablesFromFlags(cmd, configFilePath)
 	if err != nil {
 		return err
 	}
 
-	// Output the rendered config to stdout
-	fmt.Print(string(configContent))
-	return nil
-}
+	if showValues {
+		// Output the merged values to stdout
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		if err := encoder.Encode(vars); err != nil {
+			return fmt.Errorf("failed to marshal values: %w", err)
+		}
+		return encoder.Close()
+	}
 
-func runList(cmd *cobra.Command, args []string) error {
-	configFilePath, err := cmd.Flags().GetString("config")
+	strict, err := strictFromFlags(cmd)
 	if err != nil {
 		return err
 	}
-
-	valuesFilePath, err := cmd.Flags().GetString("values")
+	templateOpts, err := templateOptionsFromFlags(cmd, configFilePath)
 	if err != nil {
 		return err
 	}
 
-	// Load and potentially render the config
-	configContent, err := loadConfigWithTemplate(configFilePath, valuesFilePath)
+	// Load and render the config
+	configContent, accessedFiles, err := loadConfigWithTemplate(configFilePath, vars, strict, templateOpts)
 	if err != nil {
 		return err
 	}
 
-	cfg := config.Config{}
-	if err := yaml.Unmarshal(configContent, &cfg); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+	if showFiles {
+		// Output the files the rendered config and the configs it includes depend on to stdout
+		cfg := config.Config{}
+		if err := yaml.Unmarshal(configContent, &cfg); err != nil {
+			return fmt.Errorf("failed to parse config: %w", err)
+		}
+		if err := cfg.ResolveIncludes(configFilePath, newConfigLoader(vars, strict, templateOpts, &accessedFiles)); err != nil {
+			return err
+		}
+		valuesFilePaths, err := cmd.Flags().GetStringArray("values")
+		if err != nil {
+			return err
+		}
+		for _, valuesFilePath := range valuesFilePaths {
+			if abs, err := filepath.Abs(valuesFilePath); err == nil {
+				valuesFilePath = abs
+			}
+			accessedFiles = append(accessedFiles, valuesFilePath)
+		}
+		sort.Strings(accessedFiles)
+		for _, file := range slices.Compact(accessedFiles) {
+			fmt.Fprintln(cmd.OutOrStdout(), file)
+		}
+		return nil
 	}
 
-	// Extract config directory for relative path calculations
-	configDir := filepath.Dir(configFilePath)
+	// Output the rendered config to stdout
+	fmt.Print(string(configContent))
+	return nil
+}
 
-	// Validate configuration before listing
-	if err := cfg.ValidateConfig(configDir); err != nil {
-		slog.Error("Configuration validation failed", "error", err)
+func runList(cmd *cobra.Command, args []string) error {
+	configFilePaths, err := configPathsFromFlags(cmd)
+	if err != nil {
 		return err
 	}
 
-	// Process each unit
-	for _, unit := range cfg.Units {
-		if err := listFilesInUnit(unit, configDir); err != nil {
-			return fmt.Errorf("failed to list files for unit: %w", err)
+	for _, configFilePath := range configFilePaths {
+		vars, cfg, configDir, err := loadConfigRun(cmd, configFilePath)
+		if err != nil {
+			return err
+		}
+
+		// Output directories are relative to their config, so the units of several configs are
+		// grouped under the path of their config to tell them apart
+		if len(configFilePaths) > 1 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Config: %s\n\n", configFilePath)
+		}
+
+		// Validate configuration before listing
+		if err := cfg.ValidateConfig(configDir); err != nil {
+			slog.Error("Configuration validation failed", "config", configFilePath, "error", err)
+			return err
+		}
+
+		opts, err := runnerOptionsFromFlags(cmd, vars)
+		if err != nil {
+			return err
+		}
+		r := runner.New(slog.Default(), opts...)
+
+		// Process each unit
+		for _, unit := range cfg.Units {
+			// Templated filenames are rendered the same way as when generating
+			unit, err := r.ExpandUnit(unit)
+			if err != nil {
+				return fmt.Errorf("failed to list files for unit: %w", err)
+			}
+			if err := listFilesInUnit(cmd.OutOrStdout(), unit, configDir); err != nil {
+				return fmt.Errorf("failed to list files for unit: %w", err)
+			}
 		}
 	}
 
 	return nil
 }
 
-func listFilesInUnit(unit config.Unit, configDir string) error {
+func listFilesInUnit(w io.Writer, unit config.Unit, configDir string) error {
 	// Calculate absolute output directory path
 	outputDir := unit.OutputDirectory
 	if !filepath.IsAbs(outputDir) {
@@ -177,7 +327,7 @@ func listFilesInUnit(unit config.Unit, configDir string) error {
 	}
 
 	// Print output directory header
-	fmt.Printf("OutputDirectory: %s\n", unit.OutputDirectory)
+	fmt.Fprintf(w, "OutputDirectory: %s\n", unit.OutputDirectory)
 
 	// Create a map for quick lookup of expected files
 	expectedMap := make(map[string]bool)
@@ -199,39 +349,27 @@ func listFilesInUnit(unit config.Unit, configDir string) error {
 
 	// Print managed files
 	if len(managedFiles) > 0 {
-		fmt.Println("Managed:")
+		fmt.Fprintln(w, "Managed:")
 		for _, file := range managedFiles {
-			fmt.Printf("- %s\n", file)
+			fmt.Fprintf(w, "- %s\n", file)
 		}
 	}
 
 	// Print unmanaged files
 	if len(unmanagedFiles) > 0 {
-		fmt.Println("Unmanaged:")
+		fmt.Fprintln(w, "Unmanaged:")
 		for _, file := range unmanagedFiles {
-			fmt.Printf("- %s\n", file)
+			fmt.Fprintf(w, "- %s\n", file)
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 	return nil
 }
 
 func generateExpectedFiles(unit config.Unit) []string {
-	var files []string
-	filenameCounter := map[string]int{}
-
-	// Generate YAML files from values
-	for _, value := range unit.Values {
-		var filename string
-		if counter, exists := filenameCounter[value.Filename]; exists {
-			filename = fmt.Sprintf("%s-%d.yaml", value.Filename, counter+1)
-		} else {
-			filename = fmt.Sprintf("%s.yaml", value.Filename)
-		}
-		files = append(files, filename)
-		filenameCounter[value.Filename]++
-	}
+	// Generated YAML files (one per value, or a single file in multiDocument mode)
+	files := unit.OutputFilenames()
 
 	// Add kustomization.yaml if kustomize is configured
 	if unit.Kustomize != nil && unit.Kustomize.UpdateResources {
@@ -259,13 +397,211 @@ func getActualFiles(outputDir string) ([]string, error) {
 	return files, nil
 }
 
-func loadConfigWithTemplate(configFilePath, valuesFilePath string) ([]byte, error) {
-	if valuesFilePath == "" {
-		// No template rendering, load config directly
-		return os.ReadFile(configFilePath)
+// loadConfigFromFlags loads, renders and parses the config at configFilePath, merging the units of
+// the configs it includes. Configs that are templates are rendered with vars, see loadConfigWithTemplate.
+// It also returns the config directory used for relative path calculations.
+func loadConfigFromFlags(cmd *cobra.Command, configFilePath string, vars map[string]any) (config.Config, string, error) {
+	strict, err := strictFromFlags(cmd)
+	if err != nil {
+		return config.Config{}, "", err
+	}
+	templateOpts, err := templateOptionsFromFlags(cmd, configFilePath)
+	if err != nil {
+		return config.Config{}, "", err
+	}
+
+	// Load and potentially render the config and the configs it includes
+	load := newConfigLoader(vars, strict, templateOpts, nil)
+	cfg, err := load(configFilePath)
+	if err != nil {
+		return config.Config{}, "", err
+	}
+	if err := cfg.ResolveIncludes(configFilePath, load); err != nil {
+		return config.Config{}, "", err
+	}
+
+	// Extract config directory for relative path calculations
+	configDir := filepath.Dir(configFilePath)
+
+	return cfg, configDir, nil
+}
+
+// newConfigLoader returns a config.LoadFunc that renders and parses config files with
+// loadConfigWithTemplate. The files they are rendered from are added to accessedFiles unless it is nil.
+func newConfigLoader(vars map[string]any, strict *bool, opts []template.Option, accessedFiles *[]string) config.LoadFunc {
+	return func(path string) (config.Config, error) {
+		configContent, files, err := loadConfigWithTemplate(path, vars, strict, opts)
+		if err != nil {
+			return config.Config{}, err
+		}
+		if accessedFiles != nil {
+			*accessedFiles = append(*accessedFiles, files...)
+		}
+
+		cfg := config.Config{}
+		if err := yaml.Unmarshal(configContent, &cfg); err != nil {
+			return config.Config{}, fmt.Errorf("failed to parse config: %w", err)
+		}
+		return cfg, nil
+	}
+}
+
+// loadVariablesFromFlags merges the defaultValues of the config at configFilePath and the --values
+// files in order, and applies --set and then --set-string on top of them. It returns nil if none of
+// these are given.
+func loadVariablesFromFlags(cmd *cobra.Command, configFilePath string) (map[string]any, error) {
+	valuesFilePaths, err := cmd.Flags().GetStringArray("values")
+	if err != nil {
+		return nil, err
+	}
+	setValues, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		return nil, err
+	}
+	setStringValues, err := cmd.Flags().GetStringArray("set-string")
+	if err != nil {
+		return nil, err
 	}
 
-	// Render the config as a template
 	renderer := template.New(slog.Default())
-	return renderer.RenderConfig(configFilePath, valuesFilePath)
+	defaults, err := renderer.LoadDefaultValues(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if defaults == nil && len(valuesFilePaths) == 0 && len(setValues) == 0 && len(setStringValues) == 0 {
+		return nil, nil
+	}
+
+	values, err := renderer.LoadValuesFiles(valuesFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	vars := template.MergeVariables(defaults, values)
+	for _, assignment := range setValues {
+		if err := template.SetVariable(vars, assignment, false); err != nil {
+			return nil, fmt.Errorf("invalid --set: %w", err)
+		}
+	}
+	for _, assignment := range setStringValues {
+		if err := template.SetVariable(vars, assignment, true); err != nil {
+			return nil, fmt.Errorf("invalid --set-string: %w", err)
+		}
+	}
+	return vars, nil
+}
+
+// strictFromFlags returns the value of --strict, or nil if it is not given so that the default of
+// the API version applies
+func strictFromFlags(cmd *cobra.Command) (*bool, error) {
+	if !cmd.Flags().Changed("strict") {
+		return nil, nil
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return nil, err
+	}
+	return &strict, nil
+}
+
+// runnerOptionsFromFlags returns the runner options for the template variables and --strict
+func runnerOptionsFromFlags(cmd *cobra.Command, vars map[string]any) ([]runner.RunnerOption, error) {
+	opts := []runner.RunnerOption{runner.WithVariables(vars)}
+	strict, err := strictFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if strict != nil {
+		opts = append(opts, runner.WithStrictTemplates(*strict))
+	}
+	return opts, nil
+}
+
+// templateOptionsFromFlags returns the template renderer options for --templates and --allow-env.
+// Relative --templates paths are resolved against the directory of configFilePath. Without
+// --templates, the templatesDir of the config is used.
+func templateOptionsFromFlags(cmd *cobra.Command, configFilePath string) ([]template.Option, error) {
+	templatePatterns, err := cmd.Flags().GetStringArray("templates")
+	if err != nil {
+		return nil, err
+	}
+	allowedEnv, err := cmd.Flags().GetStringArray("allow-env")
+	if err != nil {
+		return nil, err
+	}
+	if len(templatePatterns) == 0 {
+		templatesDir, err := template.New(slog.Default()).LoadTemplatesDir(configFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if templatesDir != "" {
+			templatePatterns = []string{templatesDir}
+		}
+	}
+
+	// Included configs in other directories use the same template files as the root config
+	templatePatterns = slices.Clone(templatePatterns)
+	for i, pattern := range templatePatterns {
+		if !filepath.IsAbs(pattern) {
+			if abs, err := filepath.Abs(filepath.Join(filepath.Dir(configFilePath), pattern)); err == nil {
+				templatePatterns[i] = abs
+			}
+		}
+	}
+	return []template.Option{template.WithTemplates(templatePatterns...), template.WithAllowedEnv(allowedEnv...)}, nil
+}
+
+// loadConfigWithTemplate loads the config, rendering it as a template with vars if it is a template
+// (see template.HasTemplateVars). Otherwise the config is used as it is, and vars are only used by
+// the runner, e.g. for when conditions and value variables. If vars is nil, a template is rendered
+// with empty values.
+// It also returns the files the config was rendered from, see template.TemplateRenderer.AccessedFiles.
+// If strict is nil, the config is rendered strictly only when one of its units uses an API version
+// that is strict by default, which is only known once the config has been rendered and parsed.
+func loadConfigWithTemplate(configFilePath string, vars map[string]any, strict *bool, opts []template.Option) ([]byte, []string, error) {
+	render := func(vars map[string]any, strict bool, extraOpts ...template.Option) ([]byte, []string, error) {
+		renderer := template.New(slog.Default(), append(append([]template.Option{template.WithStrict(strict)}, opts...), extraOpts...)...)
+		configContent, err := renderer.RenderConfigWithVariables(configFilePath, vars)
+		return configContent, renderer.AccessedFiles(), err
+	}
+
+	configContent, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !template.HasTemplateVars(string(configContent)) {
+		// No template rendering, use the config as it is. Argo expressions such as
+		// {{inputs.parameters.message}} are left alone even if values are given.
+		absPath, err := filepath.Abs(configFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return configContent, []string{absPath}, nil
+	}
+
+	if vars == nil {
+		// A template without any values only renders if it does not need them. Missing values that
+		// are handled by the template, e.g. with default, are fine, so the template is not rendered
+		// strictly unless --strict is given; only "<no value>" in the output is rejected.
+		renderedContent, accessedFiles, err := render(map[string]any{}, strict != nil && *strict, template.WithNoValueCheck(strict == nil))
+		if err != nil {
+			return nil, nil, fmt.Errorf("config %s is a template; pass --values or declare defaultValues: %w", configFilePath, err)
+		}
+		return renderedContent, accessedFiles, nil
+	}
+
+	// Render the config as a template
+	if strict != nil {
+		return render(vars, *strict)
+	}
+
+	renderedContent, accessedFiles, err := render(vars, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := config.Config{}
+	if err := yaml.Unmarshal(renderedContent, &cfg); err != nil || !cfg.StrictTemplatesByDefault() {
+		// Parse errors are reported by the caller
+		return renderedContent, accessedFiles, nil
+	}
+	return render(vars, true)
 }
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_ValuesWithoutConfigTemplate(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, configPath, `units:
+  - outputDirectory: ./out
+    apiVersion: v1alpha1
+    values:
+      - filename: backup
+        paths:
+          - path: "$.metadata.annotations.message"
+            value: "{{inputs.parameters.message}}"
+          - path: "$.spec.workflowSpec.activeDeadlineSeconds"
+            value: 7200
+            when:
+              - var: environment
+                equals: production
+`)
+
+	tests := []struct {
+		name        string
+		environment string
+		deadline    bool
+	}{
+		{name: "condition holds", environment: "production", deadline: true},
+		{name: "condition does not hold", environment: "staging", deadline: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := executeCommand(t, "render", "--config", configPath, "--set", "environment="+tt.environment)
+			require.NoError(t, err)
+
+			// The config is not a template, so the Argo expression is left as it is
+			assert.Contains(t, out, "message: '{{inputs.parameters.message}}'")
+			if tt.deadline {
+				assert.Contains(t, out, "activeDeadlineSeconds: 7200")
+			} else {
+				assert.NotContains(t, out, "activeDeadlineSeconds")
+			}
+		})
+	}
+}
+
+func TestRender_TemplatesDir(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "configs", "config.yaml")
+	writeFile(t, configPath, `templatesDir: ../shared
+units:
+  - outputDirectory: ./out
+    apiVersion: v1alpha1
+    values:
+      - filename: backup
+        paths:
+          - path: "$.metadata.labels.source"
+            value: {{ include "source" . }}
+`)
+	writeFile(t, filepath.Join(dir, "shared", "source.tpl"), `{{ define "source" }}shared{{ end }}`)
+	writeFile(t, filepath.Join(dir, "configs", "override", "source.tpl"), `{{ define "source" }}override{{ end }}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "templatesDir of the config", expected: "source: shared"},
+		{name: "--templates overrides templatesDir", args: []string{"--templates", "override"}, expected: "source: override"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := executeCommand(t, append([]string{"render", "--config", configPath}, tt.args...)...)
+			require.NoError(t, err)
+			assert.Contains(t, out, tt.expected)
+		})
+	}
+}
+
+func TestRender_TemplateWithoutValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		directory     string
+		expected      string
+		errorContains string
+	}{
+		{
+			name:      "missing value with default",
+			directory: `./{{ .Var.name | default "job" }}`,
+			expected:  "name: job",
+		},
+		{
+			name:          "missing value",
+			directory:     "./{{ .Var.name }}",
+			errorContains: `config.yaml is a template; pass --values or declare defaultValues: failed to render template: failed to execute template: template: config:2:26: executing "config" at <strictValue ".Var.name">: error calling strictValue: .Var.name has no value`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			writeFile(t, configPath, `units:
+  - outputDirectory: `+tt.directory+`
+    apiVersion: v1alpha1
+    values:
+      - filename: backup
+        paths:
+          - path: "$.metadata.name"
+            value: {{ .Var.name | default "job" }}
+`)
+
+			out, err := executeCommand(t, "render", "--config", configPath)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, out, tt.expected)
+		})
+	}
+}
+
+func TestList_SeveralConfigs(t *testing.T) {
+	dir := t.TempDir()
+	unit := `units:
+  - outputDirectory: ./out
+    apiVersion: v1alpha1
+    values:
+      - filename: backup
+`
+	configA := filepath.Join(dir, "a", "config.yaml")
+	configB := filepath.Join(dir, "b", "config.yaml")
+	writeFile(t, configA, unit)
+	writeFile(t, configB, unit)
+	writeFile(t, filepath.Join(dir, "b", "out", "backup.yaml"), "")
+
+	out, err := executeCommand(t, "list", "--config", filepath.Join(dir, "*", "config.yaml"))
+	require.NoError(t, err)
+
+	// The same output directory of different configs is told apart by the config heading
+	assert.Equal(t, "Config: "+configA+"\n\nOutputDirectory: ./out\n\n"+
+		"Config: "+configB+"\n\nOutputDirectory: ./out\nManaged:\n- backup.yaml\n\n", out)
+}
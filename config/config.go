@@ -6,8 +6,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 
 	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"gopkg.in/yaml.v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kyaml "sigs.k8s.io/yaml"
 )
@@ -42,10 +45,87 @@ func (av APIVersion) GetKind() string {
 	}
 }
 
+// strictAPIVersions は --strict が指定されない場合にテンプレートを strict モードで扱う API バージョン。
+// v1alpha1 は互換性のため含めない。新しい API バージョンを追加するときにここへ列挙する
+var strictAPIVersions = []APIVersion{}
+
+// IsKnown reports whether the API version is supported. An empty version is treated as v1alpha1
+func (av APIVersion) IsKnown() bool {
+	return av == "" || av == APIVersionV1Alpha1 || slices.Contains(strictAPIVersions, av)
+}
+
+// StrictTemplatesByDefault reports whether templates fail on missing variables when --strict is not given.
+// Only the versions listed in strictAPIVersions enable it, so an unknown version never does.
+func (av APIVersion) StrictTemplatesByDefault() bool {
+	return slices.Contains(strictAPIVersions, av)
+}
+
 type Config struct {
 	Units []Unit `yaml:"units"`
+	// Includes はユニットを取り込む他の設定ファイル。ファイル、ディレクトリ、glob を設定ファイルのディレクトリからの相対パスで指定する
+	Includes []string `yaml:"includes,omitempty"`
+	// DefaultValues は設定テンプレートの変数のデフォルト値。--values と --set で上書きされる
+	DefaultValues map[string]any `yaml:"defaultValues,omitempty"`
+	// TemplatesDir は設定テンプレートの include と template で使うテンプレートファイルのディレクトリまたは glob。
+	// 設定ファイルのディレクトリからの相対パスで指定する。--templates が指定された場合はそちらが優先される
+	TemplatesDir string `yaml:"templatesDir,omitempty"`
+}
+
+// StrictTemplatesByDefault reports whether any unit uses an API version whose templates are strict by default
+func (c *Config) StrictTemplatesByDefault() bool {
+	for _, unit := range c.Units {
+		if unit.APIVersion.StrictTemplatesByDefault() {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputMode controls how the generated CronWorkflows of a unit are laid out on disk
+type OutputMode string
+
+const (
+	// OutputModePerValue writes one file per value (default)
+	OutputModePerValue OutputMode = "perValue"
+	// OutputModeMultiDocument writes every value of the unit into a single multi-document YAML file
+	OutputModeMultiDocument OutputMode = "multiDocument"
+)
+
+// OutputFormat controls the serialization format of generated manifests
+type OutputFormat string
+
+const (
+	// OutputFormatYAML writes manifests as YAML (default)
+	OutputFormatYAML OutputFormat = "yaml"
+	// OutputFormatJSON writes manifests as JSON
+	OutputFormatJSON OutputFormat = "json"
+)
+
+// Extension returns the file extension (including the leading dot) for the output format
+func (of OutputFormat) Extension() string {
+	switch of {
+	case OutputFormatJSON:
+		return ".json"
+	default:
+		return ".yaml"
+	}
 }
 
+// KeyOrder controls the order of map keys in generated YAML
+type KeyOrder string
+
+const (
+	// KeyOrderAlphabetical sorts all keys alphabetically (default)
+	KeyOrderAlphabetical KeyOrder = "alphabetical"
+	// KeyOrderKubernetes uses Kubernetes-conventional order (apiVersion, kind, metadata, spec; name first in list items)
+	KeyOrderKubernetes KeyOrder = "kubernetes"
+	// KeyOrderBaseManifest keeps keys in the order they appear in the base manifest
+	KeyOrderBaseManifest KeyOrder = "baseManifest"
+)
+
+// defaultMultiDocumentFilename is the file name used in multiDocument mode when outputFilename is not set
+const defaultMultiDocumentFilename = "cronworkflows"
+
 type Unit struct {
 	BaseManifestPath *string          `yaml:"baseManifestPath"`
 	OutputDirectory  string           `yaml:"outputDirectory"`
@@ -53,6 +133,27 @@ type Unit struct {
 	Kustomize        *KustomizeConfig `yaml:"kustomize"`
 	Values           []Value          `yaml:"values"`
 	Indent           *int             `yaml:"indent,omitempty"`
+	OutputMode       OutputMode       `yaml:"outputMode,omitempty"`
+	OutputFilename   *string          `yaml:"outputFilename,omitempty"` // multiDocument モードでの出力ファイル名 (拡張子なし)
+	OutputFormat     OutputFormat     `yaml:"outputFormat,omitempty"`
+	KeyOrder         KeyOrder         `yaml:"keyOrder,omitempty"`
+	PreserveComments bool             `yaml:"preserveComments,omitempty"` // ベースマニフェストのコメントと書式を保持する
+	// Vars はこのユニットの value の filename とパスの値をテンプレートとして描画するときの変数
+	Vars map[string]any `yaml:"vars,omitempty"`
+	// TemplateBase が true の場合、ベースマニフェストを value ごとの変数で .Var として描画してから読み込む
+	TemplateBase bool `yaml:"templateBase,omitempty"`
+
+	// includes で取り込まれたユニットの場合、定義されている設定ファイルとその中でのインデックス
+	origin      string
+	originIndex int
+}
+
+// label names the unit in validation errors, using its position in its own config file
+func (u *Unit) label(index int) string {
+	if u.origin == "" {
+		return fmt.Sprintf("unit %d", index)
+	}
+	return fmt.Sprintf("unit %d of %s", u.originIndex, u.origin)
 }
 
 type KustomizeConfig struct {
@@ -68,14 +169,206 @@ func (kc *KustomizeConfig) GetRecreateFile() bool {
 	return *kc.RecreateFile
 }
 
+// ValueType is an explicit type for PathValue.Value
+type ValueType string
+
+const (
+	ValueTypeString ValueType = "string"
+	ValueTypeInt    ValueType = "int"
+	ValueTypeBool   ValueType = "bool"
+	ValueTypeJSON   ValueType = "json" // a string containing a JSON document
+	ValueTypeYAML   ValueType = "yaml" // a string containing a YAML document
+)
+
+// PathOp is the operation applied at a JSONPath target
+type PathOp string
+
+const (
+	// PathOpSet assigns the value at the path (default)
+	PathOpSet PathOp = "set"
+	// PathOpDelete removes the map key or list element at the path
+	PathOpDelete PathOp = "delete"
+	// PathOpAppend adds the value as the last element of the array at the path
+	PathOpAppend PathOp = "append"
+	// PathOpPrepend adds the value as the first element of the array at the path
+	PathOpPrepend PathOp = "prepend"
+	// PathOpInsert adds the value to the array at the path before the element at Index
+	PathOpInsert PathOp = "insert"
+	// PathOpMerge deep-merges the value into the map at the path (RFC 7386 JSON Merge Patch)
+	PathOpMerge PathOp = "merge"
+	// PathOpReplace rewrites the string at the path by replacing every match of Pattern with Replacement
+	PathOpReplace PathOp = "replace"
+)
+
+// IsArrayOp reports whether the operation adds an element to an array
+func (op PathOp) IsArrayOp() bool {
+	return op == PathOpAppend || op == PathOpPrepend || op == PathOpInsert
+}
+
 type PathValue struct {
-	Path  string `yaml:"path"`  // JSONPath式
-	Value string `yaml:"value"` // 設定する文字列値
+	Path string `yaml:"path"` // JSONPath式
+	// 設定する値。任意の YAML 値 (文字列、数値、真偽値、マップ、リスト、null) を受け付ける。
+	// Type が未指定の場合、文字列は従来どおり型推論 (例: "123" → 数値) される。
+	Value any       `yaml:"value"`
+	Type  ValueType `yaml:"type,omitempty"` // Value の型を明示的に指定する
+	Op    PathOp    `yaml:"op,omitempty"`   // パスに対する操作 (デフォルトは set)
+	// Optional が true の場合、パスが何にも一致しなくてもエラーにせず、警告を出してスキップする
+	Optional bool `yaml:"optional,omitempty"`
+	// Index は insert の挿入位置 (負の値は末尾から数える)
+	Index *int `yaml:"index,omitempty"`
+	// ValueFrom を指定すると、Value の代わりに同じドキュメント内の別のパスの現在の値をコピーする
+	ValueFrom *ValueSource `yaml:"valueFrom,omitempty"`
+	// When の条件がすべて満たされた場合のみパスを適用する
+	When []Condition `yaml:"when,omitempty"`
+	// Pattern と Replacement は replace で使う Go の正規表現と置換文字列 ($1 や ${name} でキャプチャを参照)
+	Pattern     string `yaml:"pattern,omitempty"`
+	Replacement string `yaml:"replacement,omitempty"`
+}
+
+// ValueSource refers to another field of the same document whose current value is used as the value
+type ValueSource struct {
+	Path string `yaml:"path"` // 参照元の JSONPath式
+}
+
+// Condition is a check that must hold for a PathValue to be applied.
+// Exactly one of Path, Var and Expr is set. Path and Var are tested with Equals, NotEquals or Exists,
+// and default to an existence check.
+type Condition struct {
+	Path string `yaml:"path,omitempty"` // 現在のドキュメントに対する JSONPath式
+	Var  string `yaml:"var,omitempty"`  // 変数名 (ネストしたキーはドット区切り)
+	// Expr は変数に対するフィルタ式 (例: "@.environment == 'production' && @.replicas > 1")
+	Expr      string `yaml:"expr,omitempty"`
+	Equals    any    `yaml:"equals,omitempty"`
+	NotEquals any    `yaml:"notEquals,omitempty"`
+	Exists    *bool  `yaml:"exists,omitempty"`
+}
+
+// Validate validates the condition
+func (c *Condition) Validate() error {
+	sources := 0
+	for _, s := range []string{c.Path, c.Var, c.Expr} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of path, var or expr is required")
+	}
+	if c.Path != "" && c.Path[0] != '$' {
+		return fmt.Errorf("path must be a valid JSONPath expression starting with '$', got: %s", c.Path)
+	}
+
+	checks := 0
+	for _, set := range []bool{c.Equals != nil, c.NotEquals != nil, c.Exists != nil} {
+		if set {
+			checks++
+		}
+	}
+	if c.Expr != "" && checks > 0 {
+		return fmt.Errorf("equals, notEquals and exists cannot be used with expr")
+	}
+	if checks > 1 {
+		return fmt.Errorf("only one of equals, notEquals or exists can be used")
+	}
+	return nil
+}
+
+// GetOp returns the operation, defaulting to set if not specified
+func (pv *PathValue) GetOp() PathOp {
+	if pv.Op == "" {
+		return PathOpSet
+	}
+	return pv.Op
+}
+
+// plainPathValue has the fields of PathValue without its UnmarshalYAML method
+type plainPathValue PathValue
+
+// UnmarshalYAML decodes a PathValue, keeping the literal text of scalar values when type is string
+// (e.g. `value: 007` stays "007") and defaulting an absent value to the empty string unless valueFrom is set.
+func (pv *PathValue) UnmarshalYAML(node *yaml.Node) error {
+	var plain plainPathValue
+	if err := node.Decode(&plain); err != nil {
+		return err
+	}
+	*pv = PathValue(plain)
+
+	valueNode := mappingValueNode(node, "value")
+	switch {
+	case valueNode == nil:
+		if pv.ValueFrom == nil {
+			pv.Value = ""
+		}
+	case pv.Type == ValueTypeString && valueNode.Kind == yaml.ScalarNode:
+		pv.Value = valueNode.Value
+	}
+
+	return nil
+}
+
+// mappingValueNode returns the value node for key in a mapping node, or nil if the key is absent
+func mappingValueNode(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
 }
 
 type Value struct {
 	Filename string      `yaml:"filename"`
 	Paths    []PathValue `yaml:"paths,omitempty"`
+	// Patches は RFC 6902 JSON Patch の操作列。PatchOrder に従って Paths の前後に適用される
+	Patches    []JSONPatchOperation `yaml:"patches,omitempty"`
+	PatchOrder PatchOrder           `yaml:"patchOrder,omitempty"` // デフォルトは after
+	// Vars は Unit.Vars と --values の変数より優先される、この value 固有の変数
+	Vars map[string]any `yaml:"vars,omitempty"`
+}
+
+// PatchOrder controls whether the JSON Patch operations of a value run before or after its paths
+type PatchOrder string
+
+const (
+	PatchOrderBefore PatchOrder = "before"
+	PatchOrderAfter  PatchOrder = "after"
+)
+
+// GetPatchOrder returns the patch order, defaulting to after if not specified
+func (v *Value) GetPatchOrder() PatchOrder {
+	if v.PatchOrder == "" {
+		return PatchOrderAfter
+	}
+	return v.PatchOrder
+}
+
+// JSONPatchOperation is a single RFC 6902 JSON Patch operation.
+// Path and From are JSON Pointers (RFC 6901), e.g. /spec/workflowSpec/templates/0/name
+type JSONPatchOperation struct {
+	Op    string `yaml:"op"`
+	Path  string `yaml:"path"`
+	From  string `yaml:"from,omitempty"`
+	Value any    `yaml:"value,omitempty"`
+	// HasValue は YAML で value が指定されたかどうか。value: null のように null を指定した場合も true
+	HasValue bool `yaml:"-"`
+}
+
+// plainJSONPatchOperation has the fields of JSONPatchOperation without its UnmarshalYAML method
+type plainJSONPatchOperation JSONPatchOperation
+
+// UnmarshalYAML decodes a JSONPatchOperation, recording whether value is present so that a missing
+// value can be told apart from an explicit null
+func (op *JSONPatchOperation) UnmarshalYAML(node *yaml.Node) error {
+	var plain plainJSONPatchOperation
+	if err := node.Decode(&plain); err != nil {
+		return err
+	}
+	*op = JSONPatchOperation(plain)
+	op.HasValue = mappingValueNode(node, "value") != nil
+	return nil
 }
 
 // FileReader interface for reading files (allows dependency injection for testing)
@@ -111,6 +404,43 @@ func (u *Unit) LoadBaseCronWorkflow(fileReader FileReader, configDir string) (*a
 		}, nil
 	}
 
+	data, baseManifestPath, err := u.readBaseManifest(fileReader, configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the YAML into CronWorkflow
+	var baseCronWorkflow argoworkflowsv1alpha1.CronWorkflow
+	if err := kyaml.Unmarshal(data, &baseCronWorkflow); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal base manifest file %s: %w", baseManifestPath, err)
+	}
+
+	return &baseCronWorkflow, nil
+}
+
+// LoadBaseManifestNode loads the raw base manifest as a yaml.v3 document node, keeping key order and comments.
+// It returns nil if BaseManifestPath is not provided.
+func (u *Unit) LoadBaseManifestNode(fileReader FileReader, configDir string) (*yaml.Node, error) {
+	if u.BaseManifestPath == nil {
+		return nil, nil
+	}
+
+	data, baseManifestPath, err := u.readBaseManifest(fileReader, configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse base manifest file %s: %w", baseManifestPath, err)
+	}
+
+	return &node, nil
+}
+
+// readBaseManifest reads the base manifest file, resolving its path relative to configDir.
+// It also returns the resolved path for error messages.
+func (u *Unit) readBaseManifest(fileReader FileReader, configDir string) ([]byte, string, error) {
 	// Resolve relative path from config directory
 	baseManifestPath := *u.BaseManifestPath
 	if !filepath.IsAbs(baseManifestPath) {
@@ -120,16 +450,10 @@ func (u *Unit) LoadBaseCronWorkflow(fileReader FileReader, configDir string) (*a
 	// Read the base manifest file
 	data, err := fileReader.ReadFile(baseManifestPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read base manifest file %s: %w", baseManifestPath, err)
-	}
-
-	// Unmarshal the YAML into CronWorkflow
-	var baseCronWorkflow argoworkflowsv1alpha1.CronWorkflow
-	if err := kyaml.Unmarshal(data, &baseCronWorkflow); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal base manifest file %s: %w", baseManifestPath, err)
+		return nil, baseManifestPath, fmt.Errorf("failed to read base manifest file %s: %w", baseManifestPath, err)
 	}
 
-	return &baseCronWorkflow, nil
+	return data, baseManifestPath, nil
 }
 
 // GetIndent returns the indent value for YAML generation, defaulting to 2 if not set
@@ -140,6 +464,65 @@ func (u *Unit) GetIndent() int {
 	return *u.Indent
 }
 
+// GetOutputMode returns the output mode, defaulting to perValue if not set
+func (u *Unit) GetOutputMode() OutputMode {
+	if u.OutputMode == "" {
+		return OutputModePerValue
+	}
+	return u.OutputMode
+}
+
+// GetOutputFormat returns the output format, defaulting to yaml if not set
+func (u *Unit) GetOutputFormat() OutputFormat {
+	if u.OutputFormat == "" {
+		return OutputFormatYAML
+	}
+	return u.OutputFormat
+}
+
+// GetKeyOrder returns the key order, defaulting to alphabetical if not set
+func (u *Unit) GetKeyOrder() KeyOrder {
+	if u.KeyOrder == "" {
+		return KeyOrderAlphabetical
+	}
+	return u.KeyOrder
+}
+
+// GetOutputFilename returns the base name of the single output file used in multiDocument mode
+func (u *Unit) GetOutputFilename() string {
+	if u.OutputFilename == nil || *u.OutputFilename == "" {
+		return defaultMultiDocumentFilename
+	}
+	return *u.OutputFilename
+}
+
+// ValueFilenames returns the output file name of each value in order.
+// Duplicate filenames get a numeric suffix (filename.yaml, filename-2.yaml, ...),
+// and the extension follows the output format.
+func (u *Unit) ValueFilenames() []string {
+	ext := u.GetOutputFormat().Extension()
+	filenames := make([]string, 0, len(u.Values))
+	sameFilenameCounter := map[string]int{}
+	for _, value := range u.Values {
+		if counter, exists := sameFilenameCounter[value.Filename]; exists {
+			filenames = append(filenames, fmt.Sprintf("%s-%d%s", value.Filename, counter+1, ext))
+		} else {
+			filenames = append(filenames, value.Filename+ext)
+		}
+		sameFilenameCounter[value.Filename]++
+	}
+	return filenames
+}
+
+// OutputFilenames returns the files written to the output directory for this unit
+// (excluding kustomization.yaml)
+func (u *Unit) OutputFilenames() []string {
+	if u.GetOutputMode() == OutputModeMultiDocument {
+		return []string{fmt.Sprintf("%s.yaml", u.GetOutputFilename())}
+	}
+	return u.ValueFilenames()
+}
+
 // ValidateConfig validates the configuration settings
 func (c *Config) ValidateConfig(configDir string) error {
 	if len(c.Units) == 0 {
@@ -148,15 +531,59 @@ func (c *Config) ValidateConfig(configDir string) error {
 
 	for i, unit := range c.Units {
 		if err := unit.Validate(configDir); err != nil {
-			return fmt.Errorf("validation failed for unit %d: %w", i, err)
+			return fmt.Errorf("validation failed for %s: %w", unit.label(i), err)
 		}
 	}
 
+	return c.validateOutputDirectoriesUnique(configDir)
+}
+
+// ValidateConfigWithoutOutput validates the configuration like ValidateConfig, but skips every check
+// of outputDirectory. It is used when manifests are streamed instead of written, so units do not need one.
+func (c *Config) ValidateConfigWithoutOutput(configDir string) error {
+	if len(c.Units) == 0 {
+		return fmt.Errorf("configuration must contain at least one unit")
+	}
+
+	for i, unit := range c.Units {
+		if err := unit.validateContents(configDir); err != nil {
+			return fmt.Errorf("validation failed for %s: %w", unit.label(i), err)
+		}
+	}
+
+	return nil
+}
+
+// validateOutputDirectoriesUnique checks that no two units write to the same output directory,
+// where they would overwrite each other's files and kustomization.yaml
+func (c *Config) validateOutputDirectoriesUnique(configDir string) error {
+	seen := map[string]int{}
+	for i, unit := range c.Units {
+		outputDir := unit.OutputDirectory
+		if !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(configDir, outputDir)
+		}
+		outputDir = filepath.Clean(outputDir)
+		if j, ok := seen[outputDir]; ok {
+			other := c.Units[j]
+			return fmt.Errorf("validation failed for %s: outputDirectory %s is also used by %s", unit.label(i), unit.OutputDirectory, other.label(j))
+		}
+		seen[outputDir] = i
+	}
 	return nil
 }
 
 // Validate validates a single unit configuration
 func (u *Unit) Validate(configDir string) error {
+	if err := u.validateOutputDirectory(configDir); err != nil {
+		return err
+	}
+
+	return u.validateContents(configDir)
+}
+
+// validateOutputDirectory checks that the output directory exists or can be created
+func (u *Unit) validateOutputDirectory(configDir string) error {
 	// Check output directory
 	if u.OutputDirectory == "" {
 		return fmt.Errorf("outputDirectory is required")
@@ -189,6 +616,15 @@ func (u *Unit) Validate(configDir string) error {
 		return fmt.Errorf("output directory %s exists but is not a directory", outputDir)
 	}
 
+	return nil
+}
+
+// validateContents validates everything in the unit except the output directory
+func (u *Unit) validateContents(configDir string) error {
+	if !u.APIVersion.IsKnown() {
+		return fmt.Errorf("apiVersion %q is not supported", u.APIVersion)
+	}
+
 	// Check base manifest path if provided
 	if u.BaseManifestPath != nil {
 		baseManifestPath := *u.BaseManifestPath
@@ -208,6 +644,60 @@ func (u *Unit) Validate(configDir string) error {
 		}
 	}
 
+	// Validate output mode if provided
+	switch u.GetOutputMode() {
+	case OutputModePerValue, OutputModeMultiDocument:
+	default:
+		return fmt.Errorf("outputMode must be one of %q or %q, got %q", OutputModePerValue, OutputModeMultiDocument, u.OutputMode)
+	}
+
+	if u.OutputFilename != nil && u.GetOutputMode() != OutputModeMultiDocument {
+		return fmt.Errorf("outputFilename can only be used with outputMode %q", OutputModeMultiDocument)
+	}
+
+	// Validate output format if provided
+	switch u.GetOutputFormat() {
+	case OutputFormatYAML, OutputFormatJSON:
+	default:
+		return fmt.Errorf("outputFormat must be one of %q or %q, got %q", OutputFormatYAML, OutputFormatJSON, u.OutputFormat)
+	}
+
+	// Validate key order if provided
+	switch u.GetKeyOrder() {
+	case KeyOrderAlphabetical, KeyOrderKubernetes, KeyOrderBaseManifest:
+	default:
+		return fmt.Errorf("keyOrder must be one of %q, %q or %q, got %q", KeyOrderAlphabetical, KeyOrderKubernetes, KeyOrderBaseManifest, u.KeyOrder)
+	}
+
+	if u.GetKeyOrder() == KeyOrderBaseManifest && u.BaseManifestPath == nil {
+		return fmt.Errorf("keyOrder %q requires baseManifestPath", KeyOrderBaseManifest)
+	}
+
+	if u.GetKeyOrder() != KeyOrderAlphabetical && u.GetOutputFormat() == OutputFormatJSON {
+		return fmt.Errorf("keyOrder %q is only supported with outputFormat %q", u.KeyOrder, OutputFormatYAML)
+	}
+
+	if u.TemplateBase && u.BaseManifestPath == nil {
+		return fmt.Errorf("templateBase requires baseManifestPath")
+	}
+
+	if u.PreserveComments {
+		if u.BaseManifestPath == nil {
+			return fmt.Errorf("preserveComments requires baseManifestPath")
+		}
+		if u.GetOutputFormat() != OutputFormatYAML {
+			return fmt.Errorf("preserveComments is only supported with outputFormat %q", OutputFormatYAML)
+		}
+		if u.GetKeyOrder() == KeyOrderKubernetes {
+			return fmt.Errorf("preserveComments keeps the base manifest key order and cannot be used with keyOrder %q", KeyOrderKubernetes)
+		}
+	}
+
+	// JSON has no multi-document syntax
+	if u.GetOutputFormat() == OutputFormatJSON && u.GetOutputMode() == OutputModeMultiDocument {
+		return fmt.Errorf("outputMode %q cannot be used with outputFormat %q", OutputModeMultiDocument, OutputFormatJSON)
+	}
+
 	// Check that we have at least one value
 	if len(u.Values) == 0 {
 		return fmt.Errorf("unit must contain at least one value")
@@ -236,6 +726,47 @@ func (v *Value) Validate() error {
 		}
 	}
 
+	// Validate each patch operation
+	for i, patch := range v.Patches {
+		if err := patch.Validate(); err != nil {
+			return fmt.Errorf("validation failed for patch %d: %w", i, err)
+		}
+	}
+
+	switch v.GetPatchOrder() {
+	case PatchOrderBefore, PatchOrderAfter:
+	default:
+		return fmt.Errorf("patchOrder must be either before or after, got %q", v.PatchOrder)
+	}
+
+	return nil
+}
+
+// Validate validates a single JSON Patch operation
+func (op *JSONPatchOperation) Validate() error {
+	switch op.Op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	default:
+		return fmt.Errorf("op must be one of add, remove, replace, move, copy or test, got %q", op.Op)
+	}
+
+	if op.Path != "" && op.Path[0] != '/' {
+		return fmt.Errorf("path must be a JSON Pointer starting with '/', got: %s", op.Path)
+	}
+
+	if op.Op == "move" || op.Op == "copy" {
+		if op.From == "" || op.From[0] != '/' {
+			return fmt.Errorf("from must be a JSON Pointer starting with '/' for op %s, got: %q", op.Op, op.From)
+		}
+	}
+
+	// RFC 6902 requires a value for these ops; null is a valid value, but it has to be given
+	if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+		if !op.HasValue && op.Value == nil {
+			return fmt.Errorf("value is required for op %s", op.Op)
+		}
+	}
+
 	return nil
 }
 
@@ -250,6 +781,71 @@ func (pv *PathValue) Validate() error {
 		return fmt.Errorf("path must be a valid JSONPath expression starting with '$', got: %s", pv.Path)
 	}
 
+	// Validate type if provided
+	switch pv.Type {
+	case "", ValueTypeString, ValueTypeInt, ValueTypeBool, ValueTypeJSON, ValueTypeYAML:
+	default:
+		return fmt.Errorf("type must be one of string, int, bool, json or yaml, got %q", pv.Type)
+	}
+
+	// Validate op if provided
+	switch pv.GetOp() {
+	case PathOpSet, PathOpDelete, PathOpAppend, PathOpPrepend, PathOpInsert, PathOpMerge, PathOpReplace:
+	default:
+		return fmt.Errorf("op must be one of set, delete, append, prepend, insert, merge or replace, got %q", pv.Op)
+	}
+
+	if pv.Path == "$" && pv.GetOp() == PathOpDelete {
+		return fmt.Errorf("cannot delete the document root")
+	}
+	if pv.Path == "$" && pv.GetOp().IsArrayOp() {
+		return fmt.Errorf("op %s requires an array path, the document root is not an array", pv.GetOp())
+	}
+	if pv.Path == "$" && pv.GetOp() == PathOpReplace {
+		return fmt.Errorf("op replace requires a string path, the document root is not a string")
+	}
+
+	// Index is the insert position, so it is required for insert and meaningless otherwise
+	if pv.GetOp() == PathOpInsert && pv.Index == nil {
+		return fmt.Errorf("index is required for op insert")
+	}
+	if pv.GetOp() != PathOpInsert && pv.Index != nil {
+		return fmt.Errorf("index can only be used with op insert")
+	}
+
+	// Pattern and Replacement describe the rewrite of op replace, which has no value
+	if pv.GetOp() == PathOpReplace {
+		if pv.Pattern == "" {
+			return fmt.Errorf("pattern is required for op replace")
+		}
+		if _, err := regexp.Compile(pv.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		if pv.ValueFrom != nil {
+			return fmt.Errorf("valueFrom cannot be used with op replace")
+		}
+	} else if pv.Pattern != "" || pv.Replacement != "" {
+		return fmt.Errorf("pattern and replacement can only be used with op replace")
+	}
+
+	if pv.ValueFrom != nil {
+		if pv.Value != nil {
+			return fmt.Errorf("value and valueFrom cannot be used together")
+		}
+		if pv.GetOp() == PathOpDelete {
+			return fmt.Errorf("valueFrom cannot be used with op delete")
+		}
+		if pv.ValueFrom.Path == "" || pv.ValueFrom.Path[0] != '$' {
+			return fmt.Errorf("valueFrom.path must be a valid JSONPath expression starting with '$', got: %s", pv.ValueFrom.Path)
+		}
+	}
+
+	for i := range pv.When {
+		if err := pv.When[i].Validate(); err != nil {
+			return fmt.Errorf("validation failed for when condition %d: %w", i, err)
+		}
+	}
+
 	// Value can be empty string, so no validation needed for Value field
 	return nil
 }
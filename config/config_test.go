@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestAPIVersion_GetSchemeGroupVersion(t *testing.T) {
@@ -102,6 +104,45 @@ func (m *MockFileReader) ReadFile(filename string) ([]byte, error) {
 	return nil, os.ErrNotExist // File not found
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestAPIVersion_StrictTemplatesByDefault(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion APIVersion
+		expected   bool
+	}{
+		{
+			name:       "v1alpha1 is lenient",
+			apiVersion: APIVersionV1Alpha1,
+			expected:   false,
+		},
+		{
+			name:       "empty string is lenient",
+			apiVersion: "",
+			expected:   false,
+		},
+		{
+			name:       "unknown version is not strict",
+			apiVersion: "v1alpah1",
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.apiVersion.StrictTemplatesByDefault())
+		})
+	}
+
+	cfg := Config{Units: []Unit{{APIVersion: APIVersionV1Alpha1}}}
+	assert.False(t, cfg.StrictTemplatesByDefault())
+	cfg.Units = append(cfg.Units, Unit{APIVersion: "v1alpah1"})
+	assert.False(t, cfg.StrictTemplatesByDefault())
+}
+
 func TestUnit_LoadBaseCronWorkflow_PathResolution(t *testing.T) {
 	baseManifestContent := `apiVersion: argoproj.io/v1alpha1
 kind: CronWorkflow
@@ -472,6 +513,26 @@ func TestConfig_ValidateConfig(t *testing.T) {
 			expectError:   true,
 			errorContains: "must contain at least one value",
 		},
+		{
+			name: "duplicate output directory",
+			config: Config{
+				Units: []Unit{
+					{
+						OutputDirectory: "output",
+						APIVersion:      APIVersionV1Alpha1,
+						Values:          []Value{{Filename: "first"}},
+					},
+					{
+						OutputDirectory: "./output/",
+						APIVersion:      APIVersionV1Alpha1,
+						Values:          []Value{{Filename: "second"}},
+					},
+				},
+			},
+			configDir:     tempDir,
+			expectError:   true,
+			errorContains: "validation failed for unit 1: outputDirectory ./output/ is also used by unit 0",
+		},
 	}
 
 	for _, tt := range tests {
@@ -494,6 +555,66 @@ func TestConfig_ValidateConfig(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateConfigWithoutOutput(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Run("does not create output directory", func(t *testing.T) {
+		cfg := Config{
+			Units: []Unit{
+				{
+					OutputDirectory: "not-created",
+					APIVersion:      APIVersionV1Alpha1,
+					Values:          []Value{{Filename: "test-job"}},
+				},
+			},
+		}
+
+		err := cfg.ValidateConfigWithoutOutput(tempDir)
+		assert.NoError(t, err)
+
+		_, statErr := os.Stat(filepath.Join(tempDir, "not-created"))
+		assert.True(t, os.IsNotExist(statErr))
+	})
+
+	t.Run("still validates unit contents", func(t *testing.T) {
+		cfg := Config{
+			Units: []Unit{
+				{
+					OutputDirectory: "not-created",
+					APIVersion:      APIVersionV1Alpha1,
+				},
+			},
+		}
+
+		err := cfg.ValidateConfigWithoutOutput(tempDir)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unit must contain at least one value")
+	})
+
+	t.Run("does not check outputDirectory", func(t *testing.T) {
+		cfg := Config{
+			Units: []Unit{
+				{
+					APIVersion: APIVersionV1Alpha1,
+					Values:     []Value{{Filename: "test-job"}},
+				},
+				{
+					OutputDirectory: "shared",
+					APIVersion:      APIVersionV1Alpha1,
+					Values:          []Value{{Filename: "test-job"}},
+				},
+				{
+					OutputDirectory: "./shared",
+					APIVersion:      APIVersionV1Alpha1,
+					Values:          []Value{{Filename: "test-job"}},
+				},
+			},
+		}
+
+		assert.NoError(t, cfg.ValidateConfigWithoutOutput(tempDir))
+	})
+}
+
 func TestUnit_Validate(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -557,6 +678,17 @@ func TestUnit_Validate(t *testing.T) {
 			expectError:   true,
 			errorContains: "exists but is not a directory",
 		},
+		{
+			name: "unknown api version",
+			unit: Unit{
+				OutputDirectory: "output",
+				APIVersion:      "v1alpah1",
+				Values:          []Value{{Filename: "test-job"}},
+			},
+			configDir:     tempDir,
+			expectError:   true,
+			errorContains: `apiVersion "v1alpah1" is not supported`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -715,6 +847,192 @@ func TestUnit_Validate_IndentValidation(t *testing.T) {
 	}
 }
 
+func TestUnit_OutputFilenames(t *testing.T) {
+	tests := []struct {
+		name     string
+		unit     Unit
+		expected []string
+	}{
+		{
+			name: "per value mode with duplicate filenames",
+			unit: Unit{
+				Values: []Value{
+					{Filename: "job"},
+					{Filename: "other"},
+					{Filename: "job"},
+				},
+			},
+			expected: []string{"job.yaml", "other.yaml", "job-2.yaml"},
+		},
+		{
+			name: "json output format",
+			unit: Unit{
+				OutputFormat: OutputFormatJSON,
+				Values: []Value{
+					{Filename: "job"},
+					{Filename: "job"},
+				},
+			},
+			expected: []string{"job.json", "job-2.json"},
+		},
+		{
+			name: "multiDocument mode with default filename",
+			unit: Unit{
+				OutputMode: OutputModeMultiDocument,
+				Values: []Value{
+					{Filename: "job"},
+					{Filename: "other"},
+				},
+			},
+			expected: []string{"cronworkflows.yaml"},
+		},
+		{
+			name: "multiDocument mode with custom filename",
+			unit: Unit{
+				OutputMode:     OutputModeMultiDocument,
+				OutputFilename: func() *string { s := "production"; return &s }(),
+				Values: []Value{
+					{Filename: "job"},
+				},
+			},
+			expected: []string{"production.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.unit.OutputFilenames())
+		})
+	}
+}
+
+func TestUnit_Validate_OutputOptionsValidation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name             string
+		outputMode       OutputMode
+		outputFilename   *string
+		outputFormat     OutputFormat
+		keyOrder         KeyOrder
+		preserveComments bool
+		templateBase     bool
+		expectError      bool
+		errorContains    string
+	}{
+		{
+			name:        "default output mode",
+			expectError: false,
+		},
+		{
+			name:        "perValue output mode",
+			outputMode:  OutputModePerValue,
+			expectError: false,
+		},
+		{
+			name:           "multiDocument output mode with filename",
+			outputMode:     OutputModeMultiDocument,
+			outputFilename: func() *string { s := "all"; return &s }(),
+			expectError:    false,
+		},
+		{
+			name:          "unknown output mode",
+			outputMode:    "single",
+			expectError:   true,
+			errorContains: `outputMode must be one of "perValue" or "multiDocument", got "single"`,
+		},
+		{
+			name:         "json output format",
+			outputFormat: OutputFormatJSON,
+			expectError:  false,
+		},
+		{
+			name:          "unknown output format",
+			outputFormat:  "toml",
+			expectError:   true,
+			errorContains: `outputFormat must be one of "yaml" or "json", got "toml"`,
+		},
+		{
+			name:          "multiDocument with json output format",
+			outputMode:    OutputModeMultiDocument,
+			outputFormat:  OutputFormatJSON,
+			expectError:   true,
+			errorContains: `outputMode "multiDocument" cannot be used with outputFormat "json"`,
+		},
+		{
+			name:        "kubernetes key order",
+			keyOrder:    KeyOrderKubernetes,
+			expectError: false,
+		},
+		{
+			name:          "unknown key order",
+			keyOrder:      "random",
+			expectError:   true,
+			errorContains: `keyOrder must be one of "alphabetical", "kubernetes" or "baseManifest", got "random"`,
+		},
+		{
+			name:          "baseManifest key order without base manifest",
+			keyOrder:      KeyOrderBaseManifest,
+			expectError:   true,
+			errorContains: `keyOrder "baseManifest" requires baseManifestPath`,
+		},
+		{
+			name:          "key order with json output format",
+			keyOrder:      KeyOrderKubernetes,
+			outputFormat:  OutputFormatJSON,
+			expectError:   true,
+			errorContains: `keyOrder "kubernetes" is only supported with outputFormat "yaml"`,
+		},
+		{
+			name:             "preserveComments without base manifest",
+			preserveComments: true,
+			expectError:      true,
+			errorContains:    "preserveComments requires baseManifestPath",
+		},
+		{
+			name:          "templateBase without base manifest",
+			templateBase:  true,
+			expectError:   true,
+			errorContains: "templateBase requires baseManifestPath",
+		},
+		{
+			name:           "outputFilename without multiDocument",
+			outputFilename: func() *string { s := "all"; return &s }(),
+			expectError:    true,
+			errorContains:  "outputFilename can only be used with outputMode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := Unit{
+				OutputDirectory:  "output",
+				APIVersion:       APIVersionV1Alpha1,
+				OutputMode:       tt.outputMode,
+				OutputFilename:   tt.outputFilename,
+				OutputFormat:     tt.outputFormat,
+				KeyOrder:         tt.keyOrder,
+				PreserveComments: tt.preserveComments,
+				TemplateBase:     tt.templateBase,
+				Values: []Value{
+					{Filename: "test-job"},
+				},
+			}
+
+			err := unit.Validate(tempDir)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				if tt.errorContains != "" {
+					assert.Contains(t, err.Error(), tt.errorContains)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestValue_Validate(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -784,6 +1102,72 @@ func TestValue_Validate(t *testing.T) {
 			},
 			expectError: false, // Empty value should be allowed
 		},
+		{
+			name: "valid patches",
+			value: Value{
+				Filename: "test-job",
+				Patches: []JSONPatchOperation{
+					{Op: "add", Path: "/metadata/labels/app", Value: "test"},
+					{Op: "move", From: "/metadata/labels/app", Path: "/metadata/labels/name"},
+					{Op: "test", Path: "", Value: map[string]any{}},
+				},
+				PatchOrder: PatchOrderBefore,
+			},
+			expectError: false,
+		},
+		{
+			name: "unknown patch op",
+			value: Value{
+				Filename: "test-job",
+				Patches:  []JSONPatchOperation{{Op: "merge", Path: "/metadata"}},
+			},
+			expectError:   true,
+			errorContains: `validation failed for patch 0: op must be one of add, remove, replace, move, copy or test, got "merge"`,
+		},
+		{
+			name: "patch path is not a JSON Pointer",
+			value: Value{
+				Filename: "test-job",
+				Patches:  []JSONPatchOperation{{Op: "remove", Path: "$.metadata.name"}},
+			},
+			expectError:   true,
+			errorContains: "path must be a JSON Pointer starting with '/'",
+		},
+		{
+			name: "copy without from",
+			value: Value{
+				Filename: "test-job",
+				Patches:  []JSONPatchOperation{{Op: "copy", Path: "/metadata/name"}},
+			},
+			expectError:   true,
+			errorContains: "from must be a JSON Pointer starting with '/' for op copy",
+		},
+		{
+			name: "replace without value",
+			value: Value{
+				Filename: "test-job",
+				Patches:  []JSONPatchOperation{{Op: "replace", Path: "/metadata/name"}},
+			},
+			expectError:   true,
+			errorContains: "validation failed for patch 0: value is required for op replace",
+		},
+		{
+			name: "test with explicit null value",
+			value: Value{
+				Filename: "test-job",
+				Patches:  []JSONPatchOperation{{Op: "test", Path: "/metadata/labels", HasValue: true}},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid patch order",
+			value: Value{
+				Filename:   "test-job",
+				PatchOrder: "during",
+			},
+			expectError:   true,
+			errorContains: `patchOrder must be either before or after, got "during"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -847,6 +1231,134 @@ func TestPathValue_Validate(t *testing.T) {
 			expectError:   true,
 			errorContains: "path must be a valid JSONPath expression starting with '$'",
 		},
+		{
+			name:        "explicit type",
+			pathValue:   PathValue{Path: "$.metadata.name", Value: "007", Type: ValueTypeString},
+			expectError: false,
+		},
+		{
+			name:          "unknown type",
+			pathValue:     PathValue{Path: "$.metadata.name", Value: "test", Type: "float"},
+			expectError:   true,
+			errorContains: `type must be one of string, int, bool, json or yaml, got "float"`,
+		},
+		{
+			name:        "delete op",
+			pathValue:   PathValue{Path: "$.metadata.labels.app", Op: PathOpDelete, Optional: true},
+			expectError: false,
+		},
+		{
+			name:          "unknown op",
+			pathValue:     PathValue{Path: "$.metadata.name", Value: "test", Op: "remove"},
+			expectError:   true,
+			errorContains: `op must be one of set, delete, append, prepend, insert, merge or replace, got "remove"`,
+		},
+		{
+			name:        "append op",
+			pathValue:   PathValue{Path: "$.spec.workflowSpec.templates", Value: map[string]any{"name": "sidecar"}, Op: PathOpAppend},
+			expectError: false,
+		},
+		{
+			name:        "insert op with index",
+			pathValue:   PathValue{Path: "$.spec.workflowSpec.arguments.parameters", Value: map[string]any{"name": "p"}, Op: PathOpInsert, Index: intPtr(0)},
+			expectError: false,
+		},
+		{
+			name:          "insert op without index",
+			pathValue:     PathValue{Path: "$.spec.workflowSpec.arguments.parameters", Value: "x", Op: PathOpInsert},
+			expectError:   true,
+			errorContains: "index is required for op insert",
+		},
+		{
+			name:          "index without insert op",
+			pathValue:     PathValue{Path: "$.spec.workflowSpec.arguments.parameters", Value: "x", Op: PathOpAppend, Index: intPtr(1)},
+			expectError:   true,
+			errorContains: "index can only be used with op insert",
+		},
+		{
+			name:          "append to root",
+			pathValue:     PathValue{Path: "$", Value: "x", Op: PathOpAppend},
+			expectError:   true,
+			errorContains: "op append requires an array path",
+		},
+		{
+			name:          "delete root",
+			pathValue:     PathValue{Path: "$", Op: PathOpDelete},
+			expectError:   true,
+			errorContains: "cannot delete the document root",
+		},
+		{
+			name:      "valueFrom",
+			pathValue: PathValue{Path: "$.spec.workflowMetadata.labels.cron", ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+		},
+		{
+			name:          "valueFrom with value",
+			pathValue:     PathValue{Path: "$.metadata.labels.cron", Value: "", ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+			expectError:   true,
+			errorContains: "value and valueFrom cannot be used together",
+		},
+		{
+			name:          "valueFrom with delete",
+			pathValue:     PathValue{Path: "$.metadata.labels.cron", Op: PathOpDelete, ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+			expectError:   true,
+			errorContains: "valueFrom cannot be used with op delete",
+		},
+		{
+			name:          "valueFrom without path",
+			pathValue:     PathValue{Path: "$.metadata.labels.cron", ValueFrom: &ValueSource{}},
+			expectError:   true,
+			errorContains: "valueFrom.path must be a valid JSONPath expression starting with '$', got: ",
+		},
+		{
+			name:          "valueFrom with invalid path",
+			pathValue:     PathValue{Path: "$.metadata.labels.cron", ValueFrom: &ValueSource{Path: "metadata.name"}},
+			expectError:   true,
+			errorContains: "valueFrom.path must be a valid JSONPath expression starting with '$', got: metadata.name",
+		},
+		{
+			name:      "when conditions",
+			pathValue: PathValue{Path: "$.spec.workflowSpec.retryStrategy.limit", Value: 3, When: []Condition{{Path: "$.spec.workflowSpec.templates[0].container"}, {Var: "environment", Equals: "production"}}},
+		},
+		{
+			name:          "invalid when condition",
+			pathValue:     PathValue{Path: "$.metadata.name", Value: "x", When: []Condition{{Var: "a"}, {}}},
+			expectError:   true,
+			errorContains: "validation failed for when condition 1: exactly one of path, var or expr is required",
+		},
+		{
+			name:      "replace op",
+			pathValue: PathValue{Path: "$..image", Op: PathOpReplace, Pattern: `^registry\.old/`, Replacement: "registry.new/"},
+		},
+		{
+			name:          "replace op without pattern",
+			pathValue:     PathValue{Path: "$..image", Op: PathOpReplace, Replacement: "x"},
+			expectError:   true,
+			errorContains: "pattern is required for op replace",
+		},
+		{
+			name:          "replace op with invalid pattern",
+			pathValue:     PathValue{Path: "$..image", Op: PathOpReplace, Pattern: "(unclosed"},
+			expectError:   true,
+			errorContains: "invalid pattern: error parsing regexp",
+		},
+		{
+			name:          "replace op with valueFrom",
+			pathValue:     PathValue{Path: "$..image", Op: PathOpReplace, Pattern: "a", ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+			expectError:   true,
+			errorContains: "valueFrom cannot be used with op replace",
+		},
+		{
+			name:          "replace root",
+			pathValue:     PathValue{Path: "$", Op: PathOpReplace, Pattern: "a"},
+			expectError:   true,
+			errorContains: "op replace requires a string path",
+		},
+		{
+			name:          "pattern without replace op",
+			pathValue:     PathValue{Path: "$.metadata.name", Value: "x", Pattern: "a"},
+			expectError:   true,
+			errorContains: "pattern and replacement can only be used with op replace",
+		},
 	}
 
 	for _, tt := range tests {
@@ -864,3 +1376,171 @@ func TestPathValue_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestCondition_Validate(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name          string
+		condition     Condition
+		errorContains string
+	}{
+		{name: "path existence", condition: Condition{Path: "$.spec.workflowSpec.templates[0].container"}},
+		{name: "path equals", condition: Condition{Path: "$.metadata.labels.env", Equals: "production"}},
+		{name: "var notEquals", condition: Condition{Var: "environment", NotEquals: "staging"}},
+		{name: "var exists false", condition: Condition{Var: "debug", Exists: boolPtr(false)}},
+		{name: "expr", condition: Condition{Expr: "@.environment == 'production'"}},
+		{name: "no source", condition: Condition{Equals: "x"}, errorContains: "exactly one of path, var or expr is required"},
+		{name: "path and var", condition: Condition{Path: "$.a", Var: "a"}, errorContains: "exactly one of path, var or expr is required"},
+		{name: "invalid path", condition: Condition{Path: "metadata.name"}, errorContains: "path must be a valid JSONPath expression starting with '$', got: metadata.name"},
+		{name: "expr with equals", condition: Condition{Expr: "@.a", Equals: "x"}, errorContains: "equals, notEquals and exists cannot be used with expr"},
+		{name: "equals and notEquals", condition: Condition{Var: "a", Equals: "x", NotEquals: "y"}, errorContains: "only one of equals, notEquals or exists can be used"},
+		{name: "equals and exists", condition: Condition{Var: "a", Equals: "x", Exists: boolPtr(true)}, errorContains: "only one of equals, notEquals or exists can be used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.condition.Validate()
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPathValue_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected PathValue
+	}{
+		{
+			name:     "string value",
+			input:    `{path: $.metadata.name, value: "my-job"}`,
+			expected: PathValue{Path: "$.metadata.name", Value: "my-job"},
+		},
+		{
+			name:     "native integer value",
+			input:    `{path: $.spec.failedJobsHistoryLimit, value: 3}`,
+			expected: PathValue{Path: "$.spec.failedJobsHistoryLimit", Value: 3},
+		},
+		{
+			name:     "native boolean value",
+			input:    `{path: $.spec.suspend, value: true}`,
+			expected: PathValue{Path: "$.spec.suspend", Value: true},
+		},
+		{
+			name:     "native map value",
+			input:    `{path: $.metadata.labels, value: {app: backup, team: platform}}`,
+			expected: PathValue{Path: "$.metadata.labels", Value: map[string]any{"app": "backup", "team": "platform"}},
+		},
+		{
+			name:     "native list value",
+			input:    `{path: "$.spec.workflowSpec.templates[0].container.args", value: [--verbose, 1]}`,
+			expected: PathValue{Path: "$.spec.workflowSpec.templates[0].container.args", Value: []any{"--verbose", 1}},
+		},
+		{
+			name:     "native null value",
+			input:    `{path: $.metadata.labels.app, value: null}`,
+			expected: PathValue{Path: "$.metadata.labels.app", Value: nil},
+		},
+		{
+			name:     "absent value defaults to empty string",
+			input:    `{path: $.metadata.name}`,
+			expected: PathValue{Path: "$.metadata.name", Value: ""},
+		},
+		{
+			name:     "delete op without value",
+			input:    `{path: $.metadata.labels.app, op: delete, optional: true}`,
+			expected: PathValue{Path: "$.metadata.labels.app", Value: "", Op: PathOpDelete, Optional: true},
+		},
+		{
+			name:     "insert op with index",
+			input:    `{path: $.spec.workflowSpec.templates, op: insert, index: -1, value: {name: sidecar}}`,
+			expected: PathValue{Path: "$.spec.workflowSpec.templates", Value: map[string]any{"name": "sidecar"}, Op: PathOpInsert, Index: intPtr(-1)},
+		},
+		{
+			name:     "type string keeps literal text of plain scalars",
+			input:    `{path: $.metadata.labels.code, value: 007, type: string}`,
+			expected: PathValue{Path: "$.metadata.labels.code", Value: "007", Type: ValueTypeString},
+		},
+		{
+			name:     "type string keeps literal text of exponent",
+			input:    `{path: $.metadata.labels.size, value: 1e3, type: string}`,
+			expected: PathValue{Path: "$.metadata.labels.size", Value: "1e3", Type: ValueTypeString},
+		},
+		{
+			name:     "valueFrom without value",
+			input:    `{path: $.spec.workflowMetadata.labels.cron, valueFrom: {path: $.metadata.name}}`,
+			expected: PathValue{Path: "$.spec.workflowMetadata.labels.cron", ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+		},
+		{
+			name:     "valueFrom with value",
+			input:    `{path: $.metadata.labels.cron, value: x, valueFrom: {path: $.metadata.name}}`,
+			expected: PathValue{Path: "$.metadata.labels.cron", Value: "x", ValueFrom: &ValueSource{Path: "$.metadata.name"}},
+		},
+		{
+			name:     "replace op with pattern and replacement",
+			input:    `{path: $..image, op: replace, pattern: '^registry\.old/(.*)$', replacement: 'registry.new/$1'}`,
+			expected: PathValue{Path: "$..image", Value: "", Op: PathOpReplace, Pattern: `^registry\.old/(.*)$`, Replacement: "registry.new/$1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pv PathValue
+			require.NoError(t, yaml.Unmarshal([]byte(tt.input), &pv))
+			assert.Equal(t, tt.expected, pv)
+		})
+	}
+}
+
+func TestJSONPatchOperation_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      JSONPatchOperation
+		errorContains string
+	}{
+		{
+			name:     "value",
+			input:    `{op: add, path: /metadata/labels/app, value: backup}`,
+			expected: JSONPatchOperation{Op: "add", Path: "/metadata/labels/app", Value: "backup", HasValue: true},
+		},
+		{
+			name:     "explicit null value",
+			input:    `{op: test, path: /metadata/labels, value: null}`,
+			expected: JSONPatchOperation{Op: "test", Path: "/metadata/labels", HasValue: true},
+		},
+		{
+			name:          "missing value",
+			input:         `{op: replace, path: /metadata/name}`,
+			expected:      JSONPatchOperation{Op: "replace", Path: "/metadata/name"},
+			errorContains: "value is required for op replace",
+		},
+		{
+			name:     "remove without value",
+			input:    `{op: remove, path: /metadata/labels/app}`,
+			expected: JSONPatchOperation{Op: "remove", Path: "/metadata/labels/app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var op JSONPatchOperation
+			require.NoError(t, yaml.Unmarshal([]byte(tt.input), &op))
+			assert.Equal(t, tt.expected, op)
+
+			err := op.Validate()
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
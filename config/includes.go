@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LoadFunc loads and parses the config file at path. It is used to load included config files,
+// so that they are rendered the same way as the config that includes them.
+type LoadFunc func(path string) (Config, error)
+
+// ResolveIncludes loads the config files included by the config at configPath, and the files they
+// include in turn, and appends their units to c.Units in include order.
+// Relative paths in an included unit are resolved against the directory of its own file, and are
+// rewritten relative to the directory of configPath so that the merged config can be used as one.
+// Files that include themselves, directly or indirectly, and files included more than once are errors.
+func (c *Config) ResolveIncludes(configPath string, load LoadFunc) error {
+	rootDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return err
+	}
+	resolver := &includeResolver{
+		rootDir:  rootDir,
+		load:     load,
+		included: map[string]string{},
+	}
+
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	resolver.included[absPath] = configPath
+	units, err := resolver.resolve(c.Includes, configPath, []string{absPath})
+	if err != nil {
+		return err
+	}
+	c.Units = append(c.Units, units...)
+	return nil
+}
+
+// includeResolver walks the tree of included config files
+type includeResolver struct {
+	rootDir string
+	load    LoadFunc
+	// included maps the absolute path of every file loaded so far to the file that included it
+	included map[string]string
+}
+
+// resolve loads the files matched by the includes of the config at configPath and returns their
+// units, including the units of the files they include. chain is the absolute paths of the files
+// that led to configPath, ending with configPath itself.
+func (r *includeResolver) resolve(includes []string, configPath string, chain []string) ([]Unit, error) {
+	var units []Unit
+	for _, include := range includes {
+		files, err := includeFiles(include, configPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			absPath, err := filepath.Abs(file)
+			if err != nil {
+				return nil, err
+			}
+			if i := slices.Index(chain, absPath); i >= 0 {
+				cycle := append(slices.Clone(chain[i:]), absPath)
+				return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+			}
+			if includedBy, ok := r.included[absPath]; ok {
+				return nil, fmt.Errorf("%s is included by both %s and %s", file, includedBy, configPath)
+			}
+			r.included[absPath] = configPath
+
+			cfg, err := r.load(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load included config %s: %w", file, err)
+			}
+			if len(cfg.DefaultValues) > 0 {
+				return nil, fmt.Errorf("included config %s cannot declare defaultValues; declare them in the root config", file)
+			}
+			if cfg.TemplatesDir != "" {
+				return nil, fmt.Errorf("included config %s cannot declare templatesDir; declare it in the root config", file)
+			}
+
+			fileUnits := make([]Unit, 0, len(cfg.Units))
+			for i, unit := range cfg.Units {
+				r.rebase(&unit, filepath.Dir(absPath))
+				unit.origin = file
+				unit.originIndex = i
+				fileUnits = append(fileUnits, unit)
+			}
+			units = append(units, fileUnits...)
+
+			nested, err := r.resolve(cfg.Includes, file, append(slices.Clone(chain), absPath))
+			if err != nil {
+				return nil, err
+			}
+			units = append(units, nested...)
+		}
+	}
+	return units, nil
+}
+
+// rebase rewrites the relative paths of a unit defined in dir to be relative to the root config directory
+func (r *includeResolver) rebase(unit *Unit, dir string) {
+	rel, err := filepath.Rel(r.rootDir, dir)
+	if err != nil {
+		// On another volume there is no relative path, so the directory is used as it is
+		rel = dir
+	}
+	rebasePath := func(path string) string {
+		// A missing path stays missing, so that validation still rejects it
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(rel, path)
+	}
+
+	unit.OutputDirectory = rebasePath(unit.OutputDirectory)
+	if unit.BaseManifestPath != nil {
+		baseManifestPath := rebasePath(*unit.BaseManifestPath)
+		unit.BaseManifestPath = &baseManifestPath
+	}
+}
+
+// includeFiles returns the config files matched by an include of the config at configPath, in
+// sorted order. include is a file, a directory, whose *.yaml and *.yml files are included, or a glob.
+// Relative includes are resolved against the directory of configPath.
+func includeFiles(include, configPath string) ([]string, error) {
+	pattern := include
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(configPath), pattern)
+	}
+
+	var matches []string
+	if strings.ContainsAny(include, "*?[") {
+		globMatches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("include %s of %s is not a valid glob: %w", include, configPath, err)
+		}
+		matches = globMatches
+	} else {
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("include %s of %s: %w", include, configPath, err)
+		}
+		if !info.IsDir() {
+			return []string{pattern}, nil
+		}
+		for _, ext := range []string{"*.yaml", "*.yml"} {
+			dirMatches, err := filepath.Glob(filepath.Join(pattern, ext))
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, dirMatches...)
+		}
+	}
+
+	// A directory or a glob such as *.yaml can contain the including config itself, which is skipped
+	configAbs, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, match := range matches {
+		if matchAbs, err := filepath.Abs(match); err == nil && matchAbs == configAbs {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			continue
+		}
+		files = append(files, match)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("include %s of %s matches no config files", include, configPath)
+	}
+	sort.Strings(files)
+	return files, nil
+}
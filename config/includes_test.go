@@ -0,0 +1,254 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// loadYAMLConfig parses config files without rendering them
+func loadYAMLConfig(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := Config{}
+	err = yaml.Unmarshal(content, &cfg)
+	return cfg, err
+}
+
+func TestConfig_ResolveIncludes(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		expectedDirs  []string
+		expectedBases map[string]string
+		errorContains string
+	}{
+		{
+			name: "glob, directory and nested includes",
+			files: map[string]string{
+				"config.yaml": `
+includes: [teams/*.yaml, shared]
+units:
+  - outputDirectory: ./root
+`,
+				"teams/a.yaml": `
+includes: [a/nested.yaml]
+units:
+  - outputDirectory: ./a
+    baseManifestPath: ./a/base.yaml
+`,
+				"teams/a/nested.yaml": `
+units:
+  - outputDirectory: ../nested
+    baseManifestPath: /abs/base.yaml
+`,
+				"teams/b.yaml": `
+units:
+  - outputDirectory: /abs/b
+`,
+				"shared/one.yml": `
+units:
+  - outputDirectory: one
+`,
+				"shared/notes.txt": `not a config`,
+			},
+			expectedDirs:  []string{"./root", "teams/a", "teams/nested", "/abs/b", "shared/one"},
+			expectedBases: map[string]string{"teams/a": "teams/a/base.yaml", "teams/nested": "/abs/base.yaml"},
+		},
+		{
+			name: "glob skips the including config",
+			files: map[string]string{
+				"config.yaml": `
+includes: ["*.yaml"]
+units:
+  - outputDirectory: ./root
+`,
+				"other.yaml": `
+units:
+  - outputDirectory: ./other
+`,
+			},
+			expectedDirs: []string{"./root", "other"},
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"config.yaml": `includes: [a.yaml]`,
+				"a.yaml":      `includes: [b.yaml]`,
+				"b.yaml":      `includes: [a.yaml]`,
+			},
+			errorContains: "include cycle: ",
+		},
+		{
+			name: "config including itself",
+			files: map[string]string{
+				"config.yaml": `includes: [config.yaml]`,
+			},
+			errorContains: "include cycle: ",
+		},
+		{
+			name: "file included twice",
+			files: map[string]string{
+				"config.yaml": `includes: [a.yaml, b.yaml]`,
+				"a.yaml":      `includes: [b.yaml]`,
+				"b.yaml":      `units: []`,
+			},
+			errorContains: "b.yaml is included by both",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"config.yaml": `includes: [missing.yaml]`,
+			},
+			errorContains: "include missing.yaml of",
+		},
+		{
+			name: "glob without matches",
+			files: map[string]string{
+				"config.yaml": `includes: [teams/*.yaml]`,
+			},
+			errorContains: "include teams/*.yaml of",
+		},
+		{
+			name: "defaultValues in included config",
+			files: map[string]string{
+				"config.yaml": `includes: [a.yaml]`,
+				"a.yaml":      `defaultValues: {name: a}`,
+			},
+			errorContains: "cannot declare defaultValues",
+		},
+		{
+			name: "templatesDir in included config",
+			files: map[string]string{
+				"config.yaml": `includes: [a.yaml]`,
+				"a.yaml":      `templatesDir: templates`,
+			},
+			errorContains: "cannot declare templatesDir",
+		},
+		{
+			name: "included unit without outputDirectory",
+			files: map[string]string{
+				"config.yaml":  `includes: [teams/a.yaml]`,
+				"teams/a.yaml": `units: [{values: [{filename: a}]}]`,
+			},
+			expectedDirs: []string{""},
+		},
+		{
+			name: "invalid included config",
+			files: map[string]string{
+				"config.yaml": `includes: [a.yaml]`,
+				"a.yaml":      `units: {}`,
+			},
+			errorContains: "failed to load included config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			}
+
+			configPath := filepath.Join(dir, "config.yaml")
+			cfg, err := loadYAMLConfig(configPath)
+			require.NoError(t, err)
+
+			err = cfg.ResolveIncludes(configPath, loadYAMLConfig)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+
+			var dirs []string
+			for _, unit := range cfg.Units {
+				dirs = append(dirs, unit.OutputDirectory)
+				if expected, ok := tt.expectedBases[unit.OutputDirectory]; ok {
+					require.NotNil(t, unit.BaseManifestPath)
+					assert.Equal(t, expected, *unit.BaseManifestPath)
+				}
+			}
+			assert.Equal(t, tt.expectedDirs, dirs)
+		})
+	}
+}
+
+func TestConfig_ValidateConfig_IncludedUnits(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": `
+includes: [teams]
+units:
+  - outputDirectory: ./out
+    values:
+      - filename: root
+`,
+		"teams/a.yaml": `
+units:
+  - outputDirectory: ./a
+    values:
+      - filename: a
+  - outputDirectory: ./b
+    values: []
+`,
+		"teams/b.yaml": `
+units:
+  - outputDirectory: ../out
+    values:
+      - filename: b
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	cfg, err := loadYAMLConfig(configPath)
+	require.NoError(t, err)
+	require.NoError(t, cfg.ResolveIncludes(configPath, loadYAMLConfig))
+
+	// Errors name the unit by its position in the file it is defined in
+	err = cfg.ValidateConfigWithoutOutput(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation failed for unit 1 of "+filepath.Join(dir, "teams", "a.yaml")+": unit must contain at least one value")
+
+	cfg.Units[2].Values = []Value{{Filename: "b"}}
+	err = cfg.ValidateConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation failed for unit 0 of "+filepath.Join(dir, "teams", "b.yaml")+": outputDirectory out is also used by unit 0")
+}
+
+func TestConfig_ValidateConfig_IncludedUnitWithoutOutputDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":  `includes: [teams/a.yaml]`,
+		"teams/a.yaml": `units: [{values: [{filename: a}]}]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	cfg, err := loadYAMLConfig(configPath)
+	require.NoError(t, err)
+	require.NoError(t, cfg.ResolveIncludes(configPath, loadYAMLConfig))
+
+	// The unit is not written next to the included file
+	err = cfg.ValidateConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation failed for unit 0 of "+filepath.Join(dir, "teams", "a.yaml")+": outputDirectory is required")
+}
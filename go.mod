@@ -4,7 +4,6 @@ go 1.25.7
 
 require (
 	github.com/argoproj/argo-workflows/v3 v3.7.13
-	github.com/oliveagle/jsonpath v0.1.4
 	github.com/spf13/cobra v1.10.2
 	github.com/stretchr/testify v1.11.1
 	gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,124 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+// evaluateConditions reports whether every when condition holds for the current document and variables
+func (pe *PathEvaluator) evaluateConditions(target map[string]any, conditions []config.Condition, vars map[string]any) (bool, error) {
+	for i, condition := range conditions {
+		ok, err := pe.evaluateCondition(target, condition, vars)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate when condition %d: %w", i, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateCondition evaluates a single when condition. Path conditions test the values matched in
+// the current document, var conditions test a variable, and expr conditions evaluate a filter
+// expression with @ bound to the variables.
+func (pe *PathEvaluator) evaluateCondition(target map[string]any, condition config.Condition, vars map[string]any) (bool, error) {
+	if condition.Expr != "" {
+		filter, err := parseFilterExpression(condition.Expr, 0, len(condition.Expr))
+		if err != nil {
+			return false, fmt.Errorf("invalid expr: %w", err)
+		}
+		normalized, err := normalizeJSON(vars)
+		if err != nil {
+			return false, err
+		}
+		return filter.Matches(normalized), nil
+	}
+
+	var values []any
+	if condition.Path != "" {
+		matched, err := pe.conditionPathValues(target, condition.Path)
+		if err != nil {
+			return false, err
+		}
+		values = matched
+	} else {
+		value, exists := lookupVar(vars, condition.Var)
+		if exists {
+			normalized, err := normalizeJSON(value)
+			if err != nil {
+				return false, err
+			}
+			values = []any{normalized}
+		}
+	}
+
+	switch {
+	case condition.Equals != nil:
+		return anyEqual(values, condition.Equals)
+	case condition.NotEquals != nil:
+		equal, err := anyEqual(values, condition.NotEquals)
+		return !equal, err
+	case condition.Exists != nil && !*condition.Exists:
+		return len(values) == 0, nil
+	default:
+		return len(values) > 0, nil
+	}
+}
+
+// conditionPathValues returns the values matched by a condition path, or nothing if the path does not exist
+func (pe *PathEvaluator) conditionPathValues(target map[string]any, path string) ([]any, error) {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse condition path: %w", err)
+	}
+	if len(segments) == 0 {
+		return []any{target}, nil
+	}
+
+	locations, err := resolveLocations(target, segments, false)
+	if errors.Is(err, errPathNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, 0, len(locations))
+	for _, loc := range locations {
+		value, _ := loc.get()
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// anyEqual reports whether any of the values equals expected, using the same rules as filter comparisons
+func anyEqual(values []any, expected any) (bool, error) {
+	normalized, err := normalizeJSON(expected)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range values {
+		if filterEqual(value, true, normalized, true) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lookupVar returns the variable with the given name, where dots separate the keys of nested maps
+func lookupVar(vars map[string]any, name string) (any, bool) {
+	var current any = vars
+	for _, key := range strings.Split(name, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,145 @@
+package jsonpath
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+func TestPathEvaluator_ApplyPathsWithVars_When(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+	boolPtr := func(b bool) *bool { return &b }
+
+	newBase := func() *argoworkflowsv1alpha1.CronWorkflow {
+		return &argoworkflowsv1alpha1.CronWorkflow{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "base",
+				Labels: map[string]string{"tier": "batch"},
+			},
+			Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+				WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+					Parallelism: func() *int64 { i := int64(2); return &i }(),
+					Templates: []argoworkflowsv1alpha1.Template{
+						{Name: "main", Container: &corev1.Container{Image: "busybox"}},
+						{Name: "steps"},
+					},
+				},
+			},
+		}
+	}
+	vars := map[string]any{
+		"environment": "production",
+		"replicas":    3,
+		"cluster":     map[string]any{"region": "ap-northeast-1"},
+	}
+	setName := func(when ...config.Condition) []config.PathValue {
+		return []config.PathValue{{Path: "$.metadata.name", Value: "changed", When: when}}
+	}
+
+	tests := []struct {
+		name          string
+		paths         []config.PathValue
+		vars          map[string]any
+		expectApplied bool
+		errorContains string
+	}{
+		{name: "no conditions", paths: setName(), expectApplied: true},
+		{name: "path exists", paths: setName(config.Condition{Path: "$.spec.workflowSpec.templates[0].container"}), expectApplied: true},
+		{name: "path does not exist", paths: setName(config.Condition{Path: "$.spec.workflowSpec.templates[1].container"})},
+		{name: "exists false on missing path", paths: setName(config.Condition{Path: "$.spec.workflowSpec.templates[1].container", Exists: boolPtr(false)}), expectApplied: true},
+		{name: "exists false on existing path", paths: setName(config.Condition{Path: "$.metadata.labels.tier", Exists: boolPtr(false)})},
+		{name: "path type mismatch is an error", paths: setName(config.Condition{Path: "$.metadata.name.first"}), errorContains: "is not a map"},
+		{name: "path equals", paths: setName(config.Condition{Path: "$.metadata.labels.tier", Equals: "batch"}), expectApplied: true},
+		{name: "path does not equal", paths: setName(config.Condition{Path: "$.metadata.labels.tier", Equals: "web"})},
+		{name: "number equals numerically", paths: setName(config.Condition{Path: "$.spec.workflowSpec.parallelism", Equals: 2}), expectApplied: true},
+		{name: "string equals number", paths: setName(config.Condition{Path: "$.spec.workflowSpec.parallelism", Equals: "2"}), expectApplied: true},
+		{name: "notEquals", paths: setName(config.Condition{Path: "$.metadata.labels.tier", NotEquals: "web"}), expectApplied: true},
+		{name: "notEquals on missing path", paths: setName(config.Condition{Path: "$.metadata.labels.team", NotEquals: "web"}), expectApplied: true},
+		{name: "equals on missing path", paths: setName(config.Condition{Path: "$.metadata.labels.team", Equals: "web"})},
+		{name: "any match of a wildcard equals", paths: setName(config.Condition{Path: "$.spec.workflowSpec.templates[*].name", Equals: "steps"}), expectApplied: true},
+		{name: "map equals", paths: setName(config.Condition{Path: "$.metadata.labels", Equals: map[string]any{"tier": "batch"}}), expectApplied: true},
+		{name: "var equals", paths: setName(config.Condition{Var: "environment", Equals: "production"}), vars: vars, expectApplied: true},
+		{name: "var does not equal", paths: setName(config.Condition{Var: "environment", Equals: "staging"}), vars: vars},
+		{name: "nested var", paths: setName(config.Condition{Var: "cluster.region", Equals: "ap-northeast-1"}), vars: vars, expectApplied: true},
+		{name: "var exists", paths: setName(config.Condition{Var: "replicas"}), vars: vars, expectApplied: true},
+		{name: "missing var", paths: setName(config.Condition{Var: "debug"}), vars: vars},
+		{name: "missing var without variables", paths: setName(config.Condition{Var: "environment", NotEquals: "production"}), expectApplied: true},
+		{name: "expr", paths: setName(config.Condition{Expr: "@.environment == 'production' && @.replicas > 1"}), vars: vars, expectApplied: true},
+		{name: "expr with regex on nested var", paths: setName(config.Condition{Expr: "@.cluster.region =~ /^us-/"}), vars: vars},
+		{name: "expr negation of missing var", paths: setName(config.Condition{Expr: "!@.debug"}), vars: vars, expectApplied: true},
+		{name: "invalid expr", paths: setName(config.Condition{Expr: "@.environment = 'production'"}), vars: vars, errorContains: "when condition 0: invalid expr: syntax error at column 15"},
+		{name: "invalid path", paths: setName(config.Condition{Path: "$.spec[0"}), errorContains: "failed to parse condition path"},
+		{
+			name:          "all conditions must hold",
+			paths:         setName(config.Condition{Var: "environment", Equals: "production"}, config.Condition{Path: "$.metadata.labels.team"}),
+			vars:          vars,
+			expectApplied: false,
+		},
+		{
+			name: "conditions see earlier paths",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.team", Value: "data"},
+				{Path: "$.metadata.name", Value: "changed", When: []config.Condition{{Path: "$.metadata.labels.team", Equals: "data"}}},
+			},
+			expectApplied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := newBase()
+			err := evaluator.ApplyPathsWithVars(cw, tt.paths, tt.vars)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectApplied {
+				assert.Equal(t, "changed", cw.Name)
+			} else {
+				assert.Equal(t, "base", cw.Name)
+			}
+		})
+	}
+}
+
+func TestLookupVar(t *testing.T) {
+	vars := map[string]any{
+		"name":    "job",
+		"cluster": map[string]any{"region": "ap-northeast-1", "zones": []any{"a", "c"}},
+	}
+
+	tests := []struct {
+		name           string
+		key            string
+		expected       any
+		expectedExists bool
+	}{
+		{name: "top level", key: "name", expected: "job", expectedExists: true},
+		{name: "nested", key: "cluster.region", expected: "ap-northeast-1", expectedExists: true},
+		{name: "map", key: "cluster", expected: vars["cluster"], expectedExists: true},
+		{name: "missing", key: "missing"},
+		{name: "missing nested", key: "cluster.name"},
+		{name: "through a list", key: "cluster.zones.0"},
+		{name: "through a scalar", key: "name.first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, exists := lookupVar(vars, tt.key)
+			assert.Equal(t, tt.expectedExists, exists)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
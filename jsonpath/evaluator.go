@@ -2,6 +2,7 @@ package jsonpath
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
@@ -10,21 +11,26 @@ import (
 
 	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/drumato/cron-workflow-replicator/config"
-	"github.com/oliveagle/jsonpath"
+	"gopkg.in/yaml.v3"
 )
 
-// FilterExpression represents a filter condition in a JSONPath (e.g., [?(@.name == 'task')])
-type FilterExpression struct {
-	Key   string // filter target key (e.g., "name")
-	Value string // value to match (e.g., "task")
-}
-
-// PathSegment represents a segment in a JSONPath
+// PathSegment represents a segment in a JSONPath: a key (or "*" for every key) optionally
+// followed by one subscript, or a subscript chained to the previous segment as in [0][1]
 type PathSegment struct {
 	Key        string
 	ArrayIndex *int              // nil if not an array access
 	IsNegative bool              // true for negative indices like [-1]
 	Filter     *FilterExpression // nil if not a filter expression
+	Wildcard   bool              // true for [*], every element of the array
+	Slice      *SliceExpression  // nil if not a slice like [0:3]
+	Recursive  bool              // true for ..key, matching the key at any depth
+	Quoted     bool              // true for ['key'], where the key is taken literally
+	Chained    bool              // true for a subscript without a key, applied to the elements selected by the previous segment
+}
+
+// isKeyWildcard reports whether the segment is .* and matches every key of a map
+func (s PathSegment) isKeyWildcard() bool {
+	return s.Key == "*" && !s.Quoted
 }
 
 // PathEvaluator handles JSONPath evaluation and value setting
@@ -41,6 +47,12 @@ func NewPathEvaluator(logger *slog.Logger) *PathEvaluator {
 
 // ApplyPaths applies all path-value pairs to the target CronWorkflow
 func (pe *PathEvaluator) ApplyPaths(target *argoworkflowsv1alpha1.CronWorkflow, paths []config.PathValue) error {
+	return pe.ApplyPathsWithVars(target, paths, nil)
+}
+
+// ApplyPathsWithVars applies all path-value pairs to the target CronWorkflow.
+// vars are the variables that when conditions can refer to.
+func (pe *PathEvaluator) ApplyPathsWithVars(target *argoworkflowsv1alpha1.CronWorkflow, paths []config.PathValue, vars map[string]any) error {
 	if len(paths) == 0 {
 		return nil // Nothing to apply
 	}
@@ -53,13 +65,30 @@ func (pe *PathEvaluator) ApplyPaths(target *argoworkflowsv1alpha1.CronWorkflow,
 
 	// Apply each path-value pair
 	for _, pv := range paths {
-		if err := pe.setValueAtPath(targetMap, pv.Path, pv.Value); err != nil {
+		// Conditions are evaluated against the document as modified by the earlier paths
+		if len(pv.When) > 0 {
+			ok, err := pe.evaluateConditions(targetMap, pv.When, vars)
+			if err != nil {
+				return fmt.Errorf("failed to apply path %s: %w", pv.Path, err)
+			}
+			if !ok {
+				pe.logger.Debug("Skipped path whose when conditions are not met", "path", pv.Path)
+				continue
+			}
+		}
+
+		if err := pe.applyPath(targetMap, pv); err != nil {
+			if pv.Optional && errors.Is(err, errPathNotFound) {
+				pe.logger.Warn("Skipped optional path that matched nothing", "path", pv.Path, "op", pv.GetOp(), "reason", err)
+				continue
+			}
 			return fmt.Errorf("failed to apply path %s: %w", pv.Path, err)
 		}
-		pe.logger.Debug("Applied path", "path", pv.Path, "value", pv.Value)
 	}
 
-	// Convert back to CronWorkflow
+	// Convert back to CronWorkflow. The target is reset first so that removed fields do not
+	// survive from the original struct, and maps shared with the base CronWorkflow are not reused.
+	*target = argoworkflowsv1alpha1.CronWorkflow{}
 	if err := pe.mapToStruct(targetMap, target); err != nil {
 		return fmt.Errorf("failed to convert map back to CronWorkflow: %w", err)
 	}
@@ -67,6 +96,59 @@ func (pe *PathEvaluator) ApplyPaths(target *argoworkflowsv1alpha1.CronWorkflow,
 	return nil
 }
 
+// applyPath applies a single path-value pair to the target map according to its operation
+func (pe *PathEvaluator) applyPath(target map[string]any, pv config.PathValue) error {
+	switch pv.GetOp() {
+	case config.PathOpDelete:
+		if err := pe.deleteAtPath(target, pv.Path); err != nil {
+			return err
+		}
+		pe.logger.Debug("Deleted path", "path", pv.Path)
+		return nil
+	case config.PathOpAppend, config.PathOpPrepend, config.PathOpInsert:
+		value, err := pe.resolveValue(target, pv)
+		if err != nil {
+			return err
+		}
+		if err := pe.insertAtPath(target, pv.Path, pv.GetOp(), pv.Index, value); err != nil {
+			return err
+		}
+		pe.logger.Debug("Added array element", "path", pv.Path, "op", pv.GetOp(), "value", value)
+		return nil
+	case config.PathOpReplace:
+		pattern, err := regexp.Compile(pv.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		replaced, err := pe.replaceAtPath(target, pv.Path, pattern, pv.Replacement)
+		if err != nil {
+			return err
+		}
+		pe.logger.Debug("Replaced pattern", "path", pv.Path, "pattern", pv.Pattern, "replaced", replaced)
+		return nil
+	case config.PathOpMerge:
+		value, err := pe.resolveValue(target, pv)
+		if err != nil {
+			return err
+		}
+		if err := pe.mergeAtPath(target, pv.Path, value); err != nil {
+			return err
+		}
+		pe.logger.Debug("Merged path", "path", pv.Path, "value", value)
+		return nil
+	default:
+		value, err := pe.resolveValue(target, pv)
+		if err != nil {
+			return err
+		}
+		if err := pe.setValueAtPath(target, pv.Path, value); err != nil {
+			return err
+		}
+		pe.logger.Debug("Applied path", "path", pv.Path, "value", value)
+		return nil
+	}
+}
+
 // structToMap converts a struct to map[string]interface{} via JSON marshaling
 func (pe *PathEvaluator) structToMap(obj any) (map[string]any, error) {
 	// Marshal to JSON
@@ -100,6 +182,115 @@ func (pe *PathEvaluator) mapToStruct(m map[string]any, target any) error {
 	return nil
 }
 
+// resolveValue returns the value to assign for a path-value pair.
+// With valueFrom, the current value at the source path in target is used as-is, or converted if a type is given.
+// An explicit type converts the value accordingly; otherwise string values are converted
+// with the convertValue heuristic and other YAML values (numbers, bools, maps, lists, null) are used as-is.
+func (pe *PathEvaluator) resolveValue(target map[string]any, pv config.PathValue) (any, error) {
+	if pv.ValueFrom != nil {
+		value, err := pe.valueAtPath(target, pv.ValueFrom.Path)
+		if err != nil {
+			return nil, err
+		}
+		if pv.Type != "" {
+			return convertTypedValue(value, pv.Type)
+		}
+		return value, nil
+	}
+	if pv.Type != "" {
+		return convertTypedValue(pv.Value, pv.Type)
+	}
+	if s, ok := pv.Value.(string); ok {
+		return pe.convertValue(s), nil
+	}
+	return pv.Value, nil
+}
+
+// valueAtPath returns a copy of the current value at a valueFrom source path, which must match exactly one location
+func (pe *PathEvaluator) valueAtPath(target map[string]any, path string) (any, error) {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse valueFrom path: %w", err)
+	}
+	if len(segments) == 0 {
+		return deepCopy(target), nil
+	}
+
+	locations, err := resolveLocations(target, segments, false)
+	if err != nil {
+		// Not wrapped: optional only tolerates a missing target, never a missing source
+		return nil, fmt.Errorf("valueFrom path %s: %v", path, err)
+	}
+	if len(locations) != 1 {
+		return nil, fmt.Errorf("valueFrom path %s matched %d values, expected exactly one", path, len(locations))
+	}
+	value, _ := locations[0].get()
+	return deepCopy(value), nil
+}
+
+// convertTypedValue converts a value to the given explicit type
+func convertTypedValue(value any, valueType config.ValueType) (any, error) {
+	switch valueType {
+	case config.ValueTypeString:
+		if value == nil {
+			return "", nil
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(value), nil
+	case config.ValueTypeInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v != float64(int(v)) {
+				return nil, fmt.Errorf("value %v is not an integer", v)
+			}
+			return int(v), nil
+		case string:
+			intVal, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("value %q is not an integer: %w", v, err)
+			}
+			return intVal, nil
+		}
+	case config.ValueTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			boolVal, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("value %q is not a boolean: %w", v, err)
+			}
+			return boolVal, nil
+		}
+	case config.ValueTypeJSON:
+		if s, ok := value.(string); ok {
+			var jsonVal any
+			if err := json.Unmarshal([]byte(s), &jsonVal); err != nil {
+				return nil, fmt.Errorf("value is not valid JSON: %w", err)
+			}
+			return jsonVal, nil
+		}
+	case config.ValueTypeYAML:
+		if s, ok := value.(string); ok {
+			var yamlVal any
+			if err := yaml.Unmarshal([]byte(s), &yamlVal); err != nil {
+				return nil, fmt.Errorf("value is not valid YAML: %w", err)
+			}
+			return yamlVal, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown value type %q", valueType)
+	}
+
+	return nil, fmt.Errorf("cannot convert value of type %T to %s", value, valueType)
+}
+
 // convertValue attempts to convert a string value to the appropriate type
 func (pe *PathEvaluator) convertValue(value string) any {
 	// Try null conversion first
@@ -145,460 +336,271 @@ func (pe *PathEvaluator) convertValue(value string) any {
 	return value
 }
 
-// setValueAtPath sets a value at the specified JSONPath in the target map
-func (pe *PathEvaluator) setValueAtPath(target map[string]any, path string, value string) error {
-	// Convert value first to check if it's an array
-	convertedValue := pe.convertValue(value)
-
-	// Check if this is an array value and the path doesn't specify an array element
-	if arrayVal, isArray := convertedValue.([]any); isArray {
-		if !pe.isArrayElementPath(path) {
-			// This is a whole array assignment
-			return pe.setArrayValue(target, path, arrayVal)
-		}
-	}
-
-	// Compile JSONPath
-	compiled, err := jsonpath.Compile(path)
+// setValueAtPath sets a resolved value at every location matched by the JSONPath in the target map.
+// Missing maps along a definite path are created and arrays are extended as needed.
+func (pe *PathEvaluator) setValueAtPath(target map[string]any, path string, value any) error {
+	segments, err := pe.parseJSONPath(path)
 	if err != nil {
-		return fmt.Errorf("failed to compile JSONPath %s: %w", path, err)
+		return fmt.Errorf("failed to parse JSONPath: %w", err)
 	}
-
-	// Check if the path exists and what it points to
-	existingValue, err := compiled.Lookup(target)
-	if err != nil {
-		// Path doesn't exist, we need to create it
-		return pe.createPathAndSetValue(target, path, value)
+	if len(segments) == 0 {
+		// Assigning to the document root has never had an effect
+		return nil
 	}
 
-	// Path exists, replace the value
-	return pe.replaceValueAtPath(target, path, value, existingValue)
-}
-
-// createPathAndSetValue creates the path structure and sets the value
-func (pe *PathEvaluator) createPathAndSetValue(target map[string]any, path string, value string) error {
-	// Parse the JSONPath to understand the structure
-	segments, err := pe.parseJSONPath(path)
+	locations, err := resolveLocations(target, segments, true)
 	if err != nil {
-		return fmt.Errorf("failed to parse JSONPath: %w", err)
+		return err
 	}
-
-	// Build nested structure
-	current := target
-	for i, segment := range segments {
-		if i == len(segments)-1 {
-			// Last segment, set the value
-			if segment.Filter != nil {
-				// Filter-based array access at last segment: need next segment for field key
-				// This case means the path ends with a filter, set the whole matched element
-				return pe.setValueAtArrayElementByFilter(current, segment.Key, segment.Filter, "", pe.convertValue(value))
-			} else if segment.ArrayIndex != nil {
-				// Array access
-				return pe.setValueAtArrayIndex(current, segment.Key, *segment.ArrayIndex, segment.IsNegative, value)
-			} else {
-				// Regular key access
-				current[segment.Key] = pe.convertValue(value)
-			}
-		} else {
-			// Intermediate segment
-			if segment.Filter != nil {
-				// Navigate through array by filter
-				if err := pe.navigateToArrayElementByFilter(&current, segment.Key, segment.Filter); err != nil {
-					return err
-				}
-			} else if segment.ArrayIndex != nil {
-				// Navigate through array
-				if err := pe.navigateToArrayElement(&current, segment.Key, *segment.ArrayIndex, segment.IsNegative); err != nil {
-					return err
-				}
-			} else {
-				// Create map if it doesn't exist
-				if _, exists := current[segment.Key]; !exists {
-					current[segment.Key] = make(map[string]any)
-				}
-
-				// Move to next level
-				if nextMap, ok := current[segment.Key].(map[string]any); ok {
-					current = nextMap
-				} else {
-					return fmt.Errorf("path segment %s is not a map, cannot create nested structure", segment.Key)
-				}
-			}
-		}
+	for _, loc := range locations {
+		// Each location gets its own copy so later operations cannot modify several at once
+		loc.set(deepCopy(value))
 	}
 
+	pe.logger.Debug("Set value at matched locations", "path", path, "matches", len(locations))
 	return nil
 }
 
-// setValueAtArrayIndex sets a value at a specific array index
-func (pe *PathEvaluator) setValueAtArrayIndex(parent map[string]any, arrayKey string, index int, isNegative bool, value string) error {
-	// Get or create the array
-	var arr []any
-	if existing, exists := parent[arrayKey]; exists {
-		if existingArr, ok := existing.([]any); ok {
-			arr = existingArr
-		} else {
-			return fmt.Errorf("key %s exists but is not an array", arrayKey)
+// deepCopy copies the maps and slices of a value decoded from JSON or YAML
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, element := range v {
+			result[key] = deepCopy(element)
 		}
-	} else {
-		arr = make([]any, 0)
-	}
-
-	// Calculate actual index
-	actualIndex := index
-	if isNegative {
-		if len(arr) == 0 {
-			return fmt.Errorf("cannot use negative index %d on empty array", index)
-		}
-		actualIndex = len(arr) + index // index is negative, so this is len(arr) - abs(index)
-		if actualIndex < 0 {
-			return fmt.Errorf("negative index %d is out of bounds for array of length %d", index, len(arr))
-		}
-	}
-
-	// Extend array if necessary (but only for positive indices)
-	if actualIndex >= len(arr) {
-		// Extend array with nil values
-		for len(arr) <= actualIndex {
-			arr = append(arr, nil)
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, element := range v {
+			result[i] = deepCopy(element)
 		}
+		return result
 	}
-
-	// Set the value
-	arr[actualIndex] = pe.convertValue(value)
-	parent[arrayKey] = arr
-
-	return nil
+	return value
 }
 
-// navigateToArrayElement navigates to an array element and updates the current pointer
-func (pe *PathEvaluator) navigateToArrayElement(current *map[string]any, arrayKey string, index int, isNegative bool) error {
-	// Get or create the array
-	var arr []any
-	if arrayVal, exists := (*current)[arrayKey]; exists {
-		if existingArr, ok := arrayVal.([]any); ok {
-			arr = existingArr
-		} else {
-			return fmt.Errorf("key %s exists but is not an array", arrayKey)
-		}
-	} else {
-		// Create array if it doesn't exist
-		arr = make([]any, 0)
-		(*current)[arrayKey] = arr
-	}
-
-	// Calculate actual index
-	actualIndex := index
-	if isNegative {
-		if len(arr) == 0 {
-			return fmt.Errorf("cannot use negative index %d on empty array", index)
-		}
-		actualIndex = len(arr) + index // index is negative, so this is len(arr) - abs(index)
-		if actualIndex < 0 {
-			return fmt.Errorf("negative index %d is out of bounds for array of length %d", index, len(arr))
-		}
+// parseJSONPath parses a JSONPath expression into segments. Supported syntax: .key, .* (every key),
+// ..key (recursive descent), ['key'] and ["key"] for keys containing dots or slashes,
+// and subscripts after a key: [n], [-n], [*], [start:end:step] and [?(filter)]. Subscripts can
+// be chained to reach into nested arrays, e.g. steps[0][1].
+// Syntax errors report the 1-based column of the offending character.
+func (pe *PathEvaluator) parseJSONPath(path string) ([]PathSegment, error) {
+	if len(path) < 1 || path[0] != '$' {
+		return nil, fmt.Errorf("invalid JSONPath: must start with '$'")
 	}
 
-	// Extend array if necessary (but only for positive indices)
-	if actualIndex >= len(arr) && !isNegative {
-		for len(arr) <= actualIndex {
-			arr = append(arr, make(map[string]any))
+	segments := []PathSegment{}
+	pos := 1
+	for pos < len(path) {
+		switch path[pos] {
+		case '.':
+			pos++
+			recursive := pos < len(path) && path[pos] == '.'
+			if recursive {
+				pos++
+			}
+			if recursive && isQuotedKeyStart(path, pos) {
+				key, next, err := parseQuotedKey(path, pos)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, PathSegment{Key: key, Recursive: true, Quoted: true})
+				pos = next
+				continue
+			}
+			keyStart := pos
+			for pos < len(path) && path[pos] != '.' && path[pos] != '[' {
+				pos++
+			}
+			if pos == keyStart {
+				if recursive {
+					return nil, &syntaxError{column: keyStart + 1, msg: "expected a field name after '..'"}
+				}
+				// Empty keys (e.g. a trailing '.') are skipped
+				continue
+			}
+			segments = append(segments, PathSegment{Key: path[keyStart:pos], Recursive: recursive})
+		case '[':
+			if isQuotedKeyStart(path, pos) {
+				key, next, err := parseQuotedKey(path, pos)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, PathSegment{Key: key, Quoted: true})
+				pos = next
+				continue
+			}
+			if len(segments) == 0 {
+				return nil, &syntaxError{column: pos + 1, msg: "subscript must follow a field name"}
+			}
+			segment := &segments[len(segments)-1]
+			if segment.ArrayIndex != nil || segment.Filter != nil || segment.Wildcard || segment.Slice != nil {
+				// A further subscript selects within the elements matched so far
+				segments = append(segments, PathSegment{Chained: true})
+				segment = &segments[len(segments)-1]
+			}
+			next, err := parseSubscript(path, pos, segment)
+			if err != nil {
+				return nil, err
+			}
+			pos = next
+		default:
+			return nil, &syntaxError{column: pos + 1, msg: fmt.Sprintf("expected '.' or '[', got %q", path[pos])}
 		}
-		(*current)[arrayKey] = arr
 	}
 
-	// Check bounds after extension
-	if actualIndex >= len(arr) {
-		return fmt.Errorf("index %d is out of bounds for array of length %d", actualIndex, len(arr))
-	}
-
-	// Navigate to the array element
-	element := arr[actualIndex]
-	if elementMap, ok := element.(map[string]any); ok {
-		*current = elementMap
-	} else {
-		// Create a map if the element is not already a map
-		newMap := make(map[string]any)
-		arr[actualIndex] = newMap
-		(*current)[arrayKey] = arr
-		*current = newMap
-	}
-
-	return nil
+	return segments, nil
 }
 
-// navigateToArrayElementByFilter navigates to the first array element matching the filter and updates the current pointer
-func (pe *PathEvaluator) navigateToArrayElementByFilter(current *map[string]any, arrayKey string, filter *FilterExpression) error {
-	arrayVal, exists := (*current)[arrayKey]
-	if !exists {
-		return fmt.Errorf("key %s does not exist", arrayKey)
-	}
-
-	arr, ok := arrayVal.([]any)
-	if !ok {
-		return fmt.Errorf("key %s is not an array", arrayKey)
-	}
+// isQuotedKeyStart reports whether path[pos] starts a ['key'] or ["key"] segment
+func isQuotedKeyStart(path string, pos int) bool {
+	return pos+1 < len(path) && path[pos] == '[' && (path[pos+1] == '\'' || path[pos+1] == '"')
+}
 
-	for _, element := range arr {
-		elementMap, ok := element.(map[string]any)
-		if !ok {
-			continue
-		}
-		if val, exists := elementMap[filter.Key]; exists {
-			if fmt.Sprintf("%v", val) == filter.Value {
-				*current = elementMap
-				return nil
+// parseQuotedKey parses the ['key'] or ["key"] segment starting at path[start] == '[' and returns
+// the key and the position after the closing bracket. Inside the quotes a backslash escapes the
+// next character, so \' and \\ produce a literal quote and backslash.
+func parseQuotedKey(path string, start int) (string, int, error) {
+	quote := path[start+1]
+	var b strings.Builder
+	for pos := start + 2; pos < len(path); pos++ {
+		switch ch := path[pos]; {
+		case ch == '\\' && pos+1 < len(path):
+			pos++
+			b.WriteByte(path[pos])
+		case ch == quote:
+			if pos+1 >= len(path) || path[pos+1] != ']' {
+				return "", 0, &syntaxError{column: pos + 2, msg: "expected ']' after quoted key"}
 			}
+			return b.String(), pos + 2, nil
+		default:
+			b.WriteByte(ch)
 		}
 	}
-
-	return fmt.Errorf("no element matching filter [?(@.%s == '%s')] found in array %s", filter.Key, filter.Value, arrayKey)
+	return "", 0, &syntaxError{column: start + 2, msg: "unterminated string"}
 }
 
-// setValueAtArrayElementByFilter sets a value on the first array element matching the filter
-func (pe *PathEvaluator) setValueAtArrayElementByFilter(parent map[string]any, arrayKey string, filter *FilterExpression, fieldKey string, value any) error {
-	arrayVal, exists := parent[arrayKey]
-	if !exists {
-		return fmt.Errorf("key %s does not exist", arrayKey)
-	}
-
-	arr, ok := arrayVal.([]any)
-	if !ok {
-		return fmt.Errorf("key %s is not an array", arrayKey)
-	}
-
-	for _, element := range arr {
-		elementMap, ok := element.(map[string]any)
-		if !ok {
-			continue
+// parseSubscript parses the bracket expression starting at path[start] == '[' into segment
+// and returns the position after the closing bracket
+func parseSubscript(path string, start int, segment *PathSegment) (int, error) {
+	if strings.HasPrefix(path[start:], "[?(") {
+		exprStart := start + 3
+		exprEnd, err := findFilterEnd(path, exprStart)
+		if err != nil {
+			return 0, err
 		}
-		if val, exists := elementMap[filter.Key]; exists {
-			if fmt.Sprintf("%v", val) == filter.Value {
-				elementMap[fieldKey] = value
-				return nil
-			}
+		filter, err := parseFilterExpression(path, exprStart, exprEnd)
+		if err != nil {
+			return 0, err
 		}
+		segment.Filter = filter
+		return exprEnd + 2, nil
 	}
 
-	return fmt.Errorf("no element matching filter [?(@.%s == '%s')] found in array %s", filter.Key, filter.Value, arrayKey)
-}
-
-// replaceValueAtPath replaces an existing value at the JSONPath
-func (pe *PathEvaluator) replaceValueAtPath(target map[string]any, path string, value string, existingValue any) error {
-	// Parse the JSONPath to understand the structure
-	segments, err := pe.parseJSONPath(path)
-	if err != nil {
-		return fmt.Errorf("failed to parse JSONPath: %w", err)
+	end := strings.IndexByte(path[start:], ']')
+	if end < 0 {
+		return 0, &syntaxError{column: start + 1, msg: "unterminated '['"}
 	}
+	end += start
+	subscript := path[start+1 : end]
 
-	// Navigate to the parent of the target field
-	current := target
-	for i, segment := range segments {
-		if i == len(segments)-1 {
-			// Last segment, set the value
-			if segment.Filter != nil {
-				return pe.setValueAtArrayElementByFilter(current, segment.Key, segment.Filter, "", pe.convertValue(value))
-			} else if segment.ArrayIndex != nil {
-				// Array access
-				return pe.setValueAtArrayIndex(current, segment.Key, *segment.ArrayIndex, segment.IsNegative, value)
-			} else {
-				// Regular key access
-				current[segment.Key] = pe.convertValue(value)
-				return nil
-			}
-		}
+	if subscript == "*" {
+		segment.Wildcard = true
+		return end + 1, nil
+	}
 
-		// Navigate to next level
-		if segment.Filter != nil {
-			if err := pe.navigateToArrayElementByFilter(&current, segment.Key, segment.Filter); err != nil {
-				return err
-			}
-		} else if segment.ArrayIndex != nil {
-			// Navigate through array
-			if err := pe.navigateToArrayElement(&current, segment.Key, *segment.ArrayIndex, segment.IsNegative); err != nil {
-				return err
-			}
-		} else {
-			if nextMap, ok := current[segment.Key].(map[string]any); ok {
-				current = nextMap
-			} else {
-				return fmt.Errorf("path segment %s is not a map, cannot navigate", segment.Key)
-			}
+	if strings.Contains(subscript, ":") {
+		slice, err := parseSlice(subscript)
+		if err != nil {
+			return 0, &syntaxError{column: start + 2, msg: err.Error()}
 		}
+		segment.Slice = slice
+		return end + 1, nil
 	}
 
-	return nil
-}
-
-// isArrayElementPath checks if the JSONPath specifies an array element (e.g., $.path[0] or $.path[0].name)
-func (pe *PathEvaluator) isArrayElementPath(path string) bool {
-	// Parse the path to check if any segment contains an array index
-	segments, err := pe.parseJSONPath(path)
+	index, err := parseIndex(subscript)
 	if err != nil {
-		return false
+		return 0, &syntaxError{column: start + 2, msg: fmt.Sprintf("invalid array index %q: %v", subscript, err)}
 	}
+	segment.ArrayIndex = &index
+	segment.IsNegative = index < 0
+	return end + 1, nil
+}
 
-	// Check if any segment has an array index or filter expression
-	for _, segment := range segments {
-		if segment.ArrayIndex != nil || segment.Filter != nil {
-			return true
-		}
+// parseIndex parses an optionally negative decimal array index
+func parseIndex(s string) (int, error) {
+	if strings.HasPrefix(s, "+") {
+		return 0, strconv.ErrSyntax
 	}
-
-	return false
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err.(*strconv.NumError).Err
+	}
+	return index, nil
 }
 
-// setArrayValue sets an entire array at the specified JSONPath
-func (pe *PathEvaluator) setArrayValue(target map[string]any, path string, arrayVal []any) error {
-	// Parse the JSONPath to understand the structure
-	segments, err := pe.parseJSONPath(path)
-	if err != nil {
-		return fmt.Errorf("failed to parse JSONPath: %w", err)
+// parseSlice parses the contents of a [start:end] or [start:end:step] subscript
+func parseSlice(subscript string) (*SliceExpression, error) {
+	parts := strings.Split(subscript, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid slice %q: too many ':'", subscript)
 	}
 
-	// Navigate to the parent of the target field
-	current := target
-	for i, segment := range segments {
-		if i == len(segments)-1 {
-			// Last segment, set the array value
-			current[segment.Key] = arrayVal
-			return nil
+	slice := &SliceExpression{Step: 1}
+	bounds := []**int{&slice.Start, &slice.End}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
 		}
-
-		// Navigate to next level
-		if segment.Filter != nil {
-			if err := pe.navigateToArrayElementByFilter(&current, segment.Key, segment.Filter); err != nil {
-				return err
-			}
-		} else if segment.ArrayIndex != nil {
-			// Navigate through array
-			if err := pe.navigateToArrayElement(&current, segment.Key, *segment.ArrayIndex, segment.IsNegative); err != nil {
-				return err
-			}
-		} else {
-			// Create map if it doesn't exist
-			if _, exists := current[segment.Key]; !exists {
-				current[segment.Key] = make(map[string]any)
-			}
-
-			// Move to next level
-			if nextMap, ok := current[segment.Key].(map[string]any); ok {
-				current = nextMap
-			} else {
-				return fmt.Errorf("path segment %s is not a map, cannot navigate", segment.Key)
-			}
+		n, err := parseIndex(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice %q: %v", subscript, err)
+		}
+		if i < 2 {
+			*bounds[i] = &n
+			continue
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("invalid slice %q: step cannot be zero", subscript)
 		}
+		slice.Step = n
 	}
-
-	return nil
+	return slice, nil
 }
 
-// splitPathSegments splits a JSONPath by dots, but ignores dots inside bracket expressions [...]
-func splitPathSegments(path string) []string {
-	var segments []string
-	var current strings.Builder
+// findFilterEnd returns the position of the ')' closing a filter expression that starts at
+// path[start], skipping over quoted strings, regular expressions and nested parentheses.
+// The ')' must be followed by ']'.
+func findFilterEnd(path string, start int) (int, error) {
 	depth := 0
-
-	for _, ch := range path {
-		switch ch {
-		case '[':
-			depth++
-			current.WriteRune(ch)
-		case ']':
-			depth--
-			current.WriteRune(ch)
-		case '.':
-			if depth == 0 {
-				if current.Len() > 0 {
-					segments = append(segments, current.String())
-					current.Reset()
+	for pos := start; pos < len(path); pos++ {
+		switch ch := path[pos]; ch {
+		case '\'', '"', '/':
+			closing := pos + 1
+			for closing < len(path) && path[closing] != ch {
+				if path[closing] == '\\' {
+					closing++
 				}
-			} else {
-				current.WriteRune(ch)
+				closing++
 			}
-		default:
-			current.WriteRune(ch)
-		}
-	}
-	if current.Len() > 0 {
-		segments = append(segments, current.String())
-	}
-
-	return segments
-}
-
-// parseJSONPath parses a JSONPath expression into segments with array index support
-func (pe *PathEvaluator) parseJSONPath(path string) ([]PathSegment, error) {
-	if len(path) < 1 || path[0] != '$' {
-		return nil, fmt.Errorf("invalid JSONPath: must start with '$'")
-	}
-
-	// Remove the leading '$'
-	path = path[1:]
-
-	// If there's a leading '.', remove it
-	if len(path) > 0 && path[0] == '.' {
-		path = path[1:]
-	}
-
-	// If path is empty after removing '$' (and potentially '.'), return empty slice
-	if path == "" {
-		return []PathSegment{}, nil
-	}
-
-	// Regular expressions for parsing
-	arrayIndexPattern := regexp.MustCompile(`^(.+)\[(-?\d+)\]$`)
-	filterPattern := regexp.MustCompile(`^(.+)\[\?\(@\.(\w+)\s*==\s*'([^']+)'\)\]$`)
-
-	// Split by dots, but not dots inside bracket expressions [...]
-	segments := []PathSegment{}
-	rawSegmentsList := splitPathSegments(path)
-
-	for _, rawSegment := range rawSegmentsList {
-		if rawSegment == "" {
-			continue
-		}
-
-		// Check if this segment has a filter expression
-		if matches := filterPattern.FindStringSubmatch(rawSegment); matches != nil {
-			key := matches[1]
-			filterKey := matches[2]
-			filterValue := matches[3]
-
-			segments = append(segments, PathSegment{
-				Key: key,
-				Filter: &FilterExpression{
-					Key:   filterKey,
-					Value: filterValue,
-				},
-			})
-		} else if matches := arrayIndexPattern.FindStringSubmatch(rawSegment); matches != nil {
-			// Check if this segment has an array index
-			key := matches[1]
-			indexStr := matches[2]
-
-			// Parse the index
-			index, err := strconv.Atoi(indexStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid array index in segment '%s': %w", rawSegment, err)
+			if closing >= len(path) {
+				return 0, &syntaxError{column: pos + 1, msg: "unterminated string"}
 			}
-
-			isNegative := index < 0
-
-			segments = append(segments, PathSegment{
-				Key:        key,
-				ArrayIndex: &index,
-				IsNegative: isNegative,
-			})
-		} else {
-			// Regular segment without array index
-			segments = append(segments, PathSegment{
-				Key:        rawSegment,
-				ArrayIndex: nil,
-				IsNegative: false,
-			})
+			pos = closing
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if pos+1 >= len(path) || path[pos+1] != ']' {
+				return 0, &syntaxError{column: pos + 2, msg: "expected ']' after filter expression"}
+			}
+			return pos, nil
 		}
 	}
-
-	return segments, nil
+	return 0, &syntaxError{column: start - 2, msg: "unterminated filter expression"}
 }
@@ -367,7 +367,7 @@ func TestPathEvaluator_parseJSONPath(t *testing.T) {
 			path: "$.spec.templates[?(@.name == 'task')].container.image",
 			expected: []PathSegment{
 				{Key: "spec", ArrayIndex: nil, IsNegative: false},
-				{Key: "templates", Filter: &FilterExpression{Key: "name", Value: "task"}},
+				{Key: "templates", Filter: mustParseFilter(t, "@.name == 'task'")},
 				{Key: "container", ArrayIndex: nil, IsNegative: false},
 				{Key: "image", ArrayIndex: nil, IsNegative: false},
 			},
@@ -376,7 +376,25 @@ func TestPathEvaluator_parseJSONPath(t *testing.T) {
 			name: "filter expression only",
 			path: "$.items[?(@.id == 'abc')]",
 			expected: []PathSegment{
-				{Key: "items", Filter: &FilterExpression{Key: "id", Value: "abc"}},
+				{Key: "items", Filter: mustParseFilter(t, "@.id == 'abc'")},
+			},
+		},
+		{
+			name: "bracket key with dots and slashes",
+			path: "$.metadata.labels['app.kubernetes.io/name']",
+			expected: []PathSegment{
+				{Key: "metadata"},
+				{Key: "labels"},
+				{Key: "app.kubernetes.io/name", Quoted: true},
+			},
+		},
+		{
+			name: "bracket key followed by an index",
+			path: "$['spec'][\"templates\"][0].name",
+			expected: []PathSegment{
+				{Key: "spec", Quoted: true},
+				{Key: "templates", Quoted: true, ArrayIndex: &[]int{0}[0]},
+				{Key: "name"},
 			},
 		},
 	}
@@ -395,6 +413,167 @@ func TestPathEvaluator_parseJSONPath(t *testing.T) {
 	}
 }
 
+func TestPathEvaluator_parseJSONPath_QuotedKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedKey   string
+		errorContains string
+	}{
+		{name: "single quotes", path: `$['app.kubernetes.io/name']`, expectedKey: "app.kubernetes.io/name"},
+		{name: "double quotes", path: `$["workflows.argoproj.io/description"]`, expectedKey: "workflows.argoproj.io/description"},
+		{name: "brackets and dots inside quotes", path: `$['a[0].b']`, expectedKey: "a[0].b"},
+		{name: "other quote style needs no escape", path: `$["it's"]`, expectedKey: "it's"},
+		{name: "escaped quote", path: `$['it\'s']`, expectedKey: "it's"},
+		{name: "escaped backslash", path: `$['C:\\temp']`, expectedKey: `C:\temp`},
+		{name: "backslash escapes any character", path: `$['\a\.b']`, expectedKey: "a.b"},
+		{name: "asterisk is a literal key", path: `$['*']`, expectedKey: "*"},
+		{name: "empty key", path: `$['']`, expectedKey: ""},
+		{name: "unterminated string", path: `$.labels['app`, errorContains: "syntax error at column 10: unterminated string"},
+		{name: "escaped closing quote", path: `$.labels['app\']`, errorContains: "syntax error at column 10: unterminated string"},
+		{name: "missing closing bracket", path: `$.labels['app'.name`, errorContains: "syntax error at column 15: expected ']' after quoted key"},
+		{name: "union is not supported", path: `$.labels['a','b']`, errorContains: "syntax error at column 13: expected ']' after quoted key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.parseJSONPath(tt.path)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, result)
+			last := result[len(result)-1]
+			assert.Equal(t, tt.expectedKey, last.Key)
+			assert.True(t, last.Quoted)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_QuotedKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	newBase := func() *argoworkflowsv1alpha1.CronWorkflow {
+		return &argoworkflowsv1alpha1.CronWorkflow{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"app.kubernetes.io/name": "batch",
+					"app":                    "legacy",
+				},
+			},
+			Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+				WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+					Templates: []argoworkflowsv1alpha1.Template{
+						{Name: "main", Metadata: argoworkflowsv1alpha1.Metadata{Labels: map[string]string{"app.kubernetes.io/component": "worker"}}},
+						{Name: "cleanup"},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		paths         []config.PathValue
+		errorContains string
+		validator     func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "overwrite an existing label",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels['app.kubernetes.io/name']", Value: "report"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app.kubernetes.io/name": "report", "app": "legacy"}, cw.Labels)
+			},
+		},
+		{
+			name: "create annotations and a key with a slash",
+			paths: []config.PathValue{
+				{Path: `$.metadata.annotations["workflows.argoproj.io/description"]`, Value: "Nightly report"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"workflows.argoproj.io/description": "Nightly report"}, cw.Annotations)
+			},
+		},
+		{
+			name: "quoted keys along the whole path",
+			paths: []config.PathValue{
+				{Path: "$['spec']['workflowSpec']['templates'][1]['metadata']['labels']['app.kubernetes.io/component']", Value: "janitor"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "janitor", cw.Spec.WorkflowSpec.Templates[1].Metadata.Labels["app.kubernetes.io/component"])
+			},
+		},
+		{
+			name: "recursive descent with a quoted key",
+			paths: []config.PathValue{
+				{Path: "$..['app.kubernetes.io/component']", Value: "runner"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "runner", cw.Spec.WorkflowSpec.Templates[0].Metadata.Labels["app.kubernetes.io/component"])
+				assert.Nil(t, cw.Spec.WorkflowSpec.Templates[1].Metadata.Labels)
+			},
+		},
+		{
+			name: "filter on a label with a dotted key",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.metadata.labels['app.kubernetes.io/component'] == 'worker')].serviceAccountName", Value: "worker-sa"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "worker-sa", cw.Spec.WorkflowSpec.Templates[0].ServiceAccountName)
+				assert.Empty(t, cw.Spec.WorkflowSpec.Templates[1].ServiceAccountName)
+			},
+		},
+		{
+			name: "delete and merge",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels['app.kubernetes.io/name']", Op: config.PathOpDelete},
+				{Path: "$.metadata['labels']", Op: config.PathOpMerge, Value: map[string]any{"app.kubernetes.io/part-of": "reports"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app": "legacy", "app.kubernetes.io/part-of": "reports"}, cw.Labels)
+			},
+		},
+		{
+			name: "asterisk in quotes is not a wildcard",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels['*']", Value: "star"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app.kubernetes.io/name": "batch", "app": "legacy", "*": "star"}, cw.Labels)
+			},
+		},
+		{
+			name: "missing quoted key is reported in bracket notation",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels['it\\'s.missing']", Op: config.PathOpDelete},
+			},
+			errorContains: `$.metadata.labels['it\'s.missing'] matched nothing`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := newBase()
+			err := evaluator.ApplyPaths(cw, tt.paths)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			tt.validator(t, cw)
+		})
+	}
+}
+
 func TestPathEvaluator_structToMapAndBack(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 	evaluator := NewPathEvaluator(logger)
@@ -518,6 +697,267 @@ func TestPathEvaluator_convertValue(t *testing.T) {
 	}
 }
 
+func TestPathEvaluator_resolveValue(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name      string
+		pv        config.PathValue
+		expected  any
+		expectErr bool
+	}{
+		{
+			name:     "string without type uses heuristic",
+			pv:       config.PathValue{Value: "007"},
+			expected: 7,
+		},
+		{
+			name:     "native integer is used as-is",
+			pv:       config.PathValue{Value: 42},
+			expected: 42,
+		},
+		{
+			name:     "native map is used as-is",
+			pv:       config.PathValue{Value: map[string]any{"a": "b"}},
+			expected: map[string]any{"a": "b"},
+		},
+		{
+			name:     "native null is used as-is",
+			pv:       config.PathValue{Value: nil},
+			expected: nil,
+		},
+		{
+			name:     "type string keeps numeric looking string",
+			pv:       config.PathValue{Value: "1e3", Type: config.ValueTypeString},
+			expected: "1e3",
+		},
+		{
+			name:     "type string keeps boolean looking string",
+			pv:       config.PathValue{Value: "true", Type: config.ValueTypeString},
+			expected: "true",
+		},
+		{
+			name:     "type string formats native values",
+			pv:       config.PathValue{Value: 10, Type: config.ValueTypeString},
+			expected: "10",
+		},
+		{
+			name:     "type int parses string",
+			pv:       config.PathValue{Value: "12", Type: config.ValueTypeInt},
+			expected: 12,
+		},
+		{
+			name:      "type int rejects non-integer string",
+			pv:        config.PathValue{Value: "1.5", Type: config.ValueTypeInt},
+			expectErr: true,
+		},
+		{
+			name:     "type bool parses string",
+			pv:       config.PathValue{Value: "false", Type: config.ValueTypeBool},
+			expected: false,
+		},
+		{
+			name:      "type bool rejects invalid string",
+			pv:        config.PathValue{Value: "yes please", Type: config.ValueTypeBool},
+			expectErr: true,
+		},
+		{
+			name:     "type json parses scalar JSON",
+			pv:       config.PathValue{Value: `"quoted"`, Type: config.ValueTypeJSON},
+			expected: "quoted",
+		},
+		{
+			name:      "type json rejects invalid JSON",
+			pv:        config.PathValue{Value: `{invalid`, Type: config.ValueTypeJSON},
+			expectErr: true,
+		},
+		{
+			name:     "type yaml parses YAML document",
+			pv:       config.PathValue{Value: "- a\n- b\n", Type: config.ValueTypeYAML},
+			expected: []any{"a", "b"},
+		},
+		{
+			name:      "type yaml requires a string",
+			pv:        config.PathValue{Value: 1, Type: config.ValueTypeYAML},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.resolveValue(map[string]any{}, tt.pv)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_ValueFrom(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	newBase := func() *argoworkflowsv1alpha1.CronWorkflow {
+		return &argoworkflowsv1alpha1.CronWorkflow{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "base",
+				Labels: map[string]string{"team": "data"},
+			},
+			Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+				Schedules: []string{"0 0 * * *"},
+				WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+					Templates: []argoworkflowsv1alpha1.Template{{Name: "main"}, {Name: "cleanup"}},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		paths         []config.PathValue
+		errorContains string
+		validator     func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "copies a value assigned earlier in the same value",
+			paths: []config.PathValue{
+				{Path: "$.metadata.name", Value: "nightly-report"},
+				{Path: "$.spec.workflowMetadata.labels.cron", ValueFrom: &config.ValueSource{Path: "$.metadata.name"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				require.NotNil(t, cw.Spec.WorkflowMetadata)
+				assert.Equal(t, "nightly-report", cw.Spec.WorkflowMetadata.Labels["cron"])
+			},
+		},
+		{
+			name: "copies a value from the base manifest",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.entrypoint", ValueFrom: &config.ValueSource{Path: "$.spec.workflowSpec.templates[0].name"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "main", cw.Spec.WorkflowSpec.Entrypoint)
+			},
+		},
+		{
+			name: "copies maps without sharing them",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowMetadata.labels", ValueFrom: &config.ValueSource{Path: "$.metadata.labels"}},
+				{Path: "$.metadata.labels.team", Value: "platform"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"team": "platform"}, cw.Labels)
+				assert.Equal(t, map[string]string{"team": "data"}, cw.Spec.WorkflowMetadata.Labels)
+			},
+		},
+		{
+			name: "appends a copied value",
+			paths: []config.PathValue{
+				{Path: "$.spec.schedules", Op: config.PathOpAppend, ValueFrom: &config.ValueSource{Path: "$.spec.schedules[0]"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"0 0 * * *", "0 0 * * *"}, cw.Spec.Schedules)
+			},
+		},
+		{
+			name: "type converts the copied value",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.parallelism", Value: 3},
+				{Path: "$.metadata.annotations.parallelism", ValueFrom: &config.ValueSource{Path: "$.spec.workflowSpec.parallelism"}, Type: config.ValueTypeString},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "3", cw.Annotations["parallelism"])
+			},
+		},
+		{
+			name: "copied strings are not type inferred",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.version", Value: "123", Type: config.ValueTypeString},
+				{Path: "$.metadata.annotations.version", ValueFrom: &config.ValueSource{Path: "$.metadata.labels.version"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "123", cw.Annotations["version"])
+			},
+		},
+		{
+			name: "missing source is an error",
+			paths: []config.PathValue{
+				{Path: "$.metadata.annotations.owner", ValueFrom: &config.ValueSource{Path: "$.metadata.labels.owner"}},
+			},
+			errorContains: "valueFrom path $.metadata.labels.owner: path not found: $.metadata.labels.owner matched nothing",
+		},
+		{
+			name: "missing source is an error even when optional",
+			paths: []config.PathValue{
+				{Path: "$.metadata.annotations.owner", ValueFrom: &config.ValueSource{Path: "$.metadata.labels.owner"}, Optional: true},
+			},
+			errorContains: "valueFrom path $.metadata.labels.owner",
+		},
+		{
+			name: "source matching several values is an error",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.entrypoint", ValueFrom: &config.ValueSource{Path: "$.spec.workflowSpec.templates[*].name"}},
+			},
+			errorContains: "valueFrom path $.spec.workflowSpec.templates[*].name matched 2 values, expected exactly one",
+		},
+		{
+			name: "invalid source path",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.entrypoint", ValueFrom: &config.ValueSource{Path: "$.spec[0"}},
+			},
+			errorContains: "failed to parse valueFrom path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := newBase()
+			err := evaluator.ApplyPaths(cw, tt.paths)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			tt.validator(t, cw)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_TypedValues(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	cw := &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+	}
+
+	paths := []config.PathValue{
+		{Path: "$.metadata.labels.enabled", Value: "true", Type: config.ValueTypeString},
+		{Path: "$.metadata.annotations", Value: map[string]any{"owner": "platform"}},
+		{Path: "$.spec.failedJobsHistoryLimit", Value: 2},
+		{Path: "$.spec.workflowSpec.arguments.parameters", Value: []any{
+			map[string]any{"name": "code", "value": "007"},
+		}},
+		{Path: "$.spec.workflowSpec.arguments.parameters[0].value", Value: "007", Type: config.ValueTypeString},
+	}
+
+	require.NoError(t, evaluator.ApplyPaths(cw, paths))
+
+	assert.Equal(t, "true", cw.Labels["enabled"])
+	assert.Equal(t, "platform", cw.Annotations["owner"])
+	require.NotNil(t, cw.Spec.FailedJobsHistoryLimit)
+	assert.Equal(t, int32(2), *cw.Spec.FailedJobsHistoryLimit)
+	require.Len(t, cw.Spec.WorkflowSpec.Arguments.Parameters, 1)
+	assert.Equal(t, "007", cw.Spec.WorkflowSpec.Arguments.Parameters[0].Value.String())
+}
+
 func TestPathEvaluator_ArrayOperations(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 	evaluator := NewPathEvaluator(logger)
@@ -744,60 +1184,6 @@ func TestPathEvaluator_ArrayValueAssignment(t *testing.T) {
 	}
 }
 
-func TestPathEvaluator_isArrayElementPath(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
-	evaluator := NewPathEvaluator(logger)
-
-	tests := []struct {
-		name     string
-		path     string
-		expected bool
-	}{
-		{
-			name:     "array element path - positive index",
-			path:     "$.spec.templates[0].name",
-			expected: true,
-		},
-		{
-			name:     "array element path - negative index",
-			path:     "$.spec.templates[-1].name",
-			expected: true,
-		},
-		{
-			name:     "array element path - multi-digit index",
-			path:     "$.spec.templates[123].name",
-			expected: true,
-		},
-		{
-			name:     "array path without index",
-			path:     "$.spec.templates",
-			expected: false,
-		},
-		{
-			name:     "nested path without array index",
-			path:     "$.spec.workflowSpec.arguments.parameters",
-			expected: false,
-		},
-		{
-			name:     "path ending with regular property",
-			path:     "$.spec.schedule",
-			expected: false,
-		},
-		{
-			name:     "filter expression path",
-			path:     "$.spec.templates[?(@.name == 'task')].image",
-			expected: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := evaluator.isArrayElementPath(tt.path)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
 func TestPathEvaluator_ErrorHandling(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 	evaluator := NewPathEvaluator(logger)
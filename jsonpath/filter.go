@@ -0,0 +1,586 @@
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FilterExpression represents a filter condition in a JSONPath (e.g., [?(@.name == 'task')]).
+// It supports nested field references (@.container.image), the comparison operators
+// ==, !=, <, <=, >, >= and =~ (regular expression), the boolean operators &&, || and !,
+// parentheses, and both single and double quoted strings.
+type FilterExpression struct {
+	Source string // expression text between [?( and )] (e.g., "@.name == 'task'")
+	root   filterNode
+}
+
+// Matches reports whether the array element satisfies the filter
+func (f *FilterExpression) Matches(element any) bool {
+	return f.root.eval(element)
+}
+
+// String returns the filter in JSONPath notation
+func (f *FilterExpression) String() string {
+	return "[?(" + f.Source + ")]"
+}
+
+// syntaxError reports a syntax error at a 1-based column of a JSONPath
+type syntaxError struct {
+	column int
+	msg    string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.column, e.msg)
+}
+
+// filterNode is a node of a parsed filter expression
+type filterNode interface {
+	eval(element any) bool
+}
+
+// logicalNode combines two expressions with && or ||
+type logicalNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (n *logicalNode) eval(element any) bool {
+	if n.op == "&&" {
+		return n.left.eval(element) && n.right.eval(element)
+	}
+	return n.left.eval(element) || n.right.eval(element)
+}
+
+// notNode negates an expression
+type notNode struct {
+	operand filterNode
+}
+
+func (n *notNode) eval(element any) bool {
+	return !n.operand.eval(element)
+}
+
+// existsNode is a bare field reference, true when the field exists
+type existsNode struct {
+	ref *referenceOperand
+}
+
+func (n *existsNode) eval(element any) bool {
+	_, ok := n.ref.resolve(element)
+	return ok
+}
+
+// comparisonNode compares two operands
+type comparisonNode struct {
+	op          string
+	left, right operand
+	pattern     *regexp.Regexp // compiled right operand of =~
+}
+
+func (n *comparisonNode) eval(element any) bool {
+	left, leftOK := n.left.resolve(element)
+
+	if n.op == "=~" {
+		s, ok := left.(string)
+		return leftOK && ok && n.pattern.MatchString(s)
+	}
+
+	right, rightOK := n.right.resolve(element)
+	switch n.op {
+	case "==":
+		return filterEqual(left, leftOK, right, rightOK)
+	case "!=":
+		return !filterEqual(left, leftOK, right, rightOK)
+	}
+
+	if !leftOK || !rightOK {
+		return false
+	}
+	cmp, ok := filterCompare(left, right)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+// operand is a value in a comparison; ok is false when a field reference does not exist
+type operand interface {
+	resolve(element any) (value any, ok bool)
+}
+
+// literalOperand is a string, number, boolean or null literal
+type literalOperand struct {
+	value any
+}
+
+func (o *literalOperand) resolve(any) (any, bool) {
+	return o.value, true
+}
+
+// referenceOperand is a field reference relative to the current element (@, @.a.b, @['a'])
+type referenceOperand struct {
+	keys []string
+}
+
+func (o *referenceOperand) resolve(element any) (any, bool) {
+	current := element
+	for _, key := range o.keys {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// filterEqual compares two resolved operands. Numbers compare numerically, and a string compares
+// equal to the string form of a number or boolean so that [?(@.id == '1')] keeps matching 1.
+func filterEqual(left any, leftOK bool, right any, rightOK bool) bool {
+	if !leftOK || !rightOK {
+		return leftOK == rightOK
+	}
+
+	leftNum, leftIsNum := toFloat(left)
+	rightNum, rightIsNum := toFloat(right)
+	if leftIsNum && rightIsNum {
+		return leftNum == rightNum
+	}
+
+	leftStr, leftIsStr := left.(string)
+	rightStr, rightIsStr := right.(string)
+	switch {
+	case leftIsStr && rightIsStr:
+		return leftStr == rightStr
+	case leftIsStr && isScalar(right):
+		return leftStr == fmt.Sprintf("%v", right)
+	case rightIsStr && isScalar(left):
+		return rightStr == fmt.Sprintf("%v", left)
+	}
+
+	return reflect.DeepEqual(left, right)
+}
+
+// filterCompare orders two numbers or two strings
+func filterCompare(left, right any) (int, bool) {
+	leftNum, leftIsNum := toFloat(left)
+	rightNum, rightIsNum := toFloat(right)
+	if leftIsNum && rightIsNum {
+		switch {
+		case leftNum < rightNum:
+			return -1, true
+		case leftNum > rightNum:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	leftStr, leftIsStr := left.(string)
+	rightStr, rightIsStr := right.(string)
+	if leftIsStr && rightIsStr {
+		return strings.Compare(leftStr, rightStr), true
+	}
+
+	return 0, false
+}
+
+// toFloat converts numeric values to float64
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// isScalar reports whether the value is a number or boolean
+func isScalar(value any) bool {
+	switch value.(type) {
+	case int, int64, float64, bool:
+		return true
+	}
+	return false
+}
+
+// filterToken is a lexical token of a filter expression
+type filterToken struct {
+	kind  filterTokenKind
+	text  string // operator or identifier text, or the unquoted string value
+	value any    // parsed value of number and keyword literals
+	pos   int    // 0-based offset in the path
+}
+
+type filterTokenKind int
+
+const (
+	tokenEOF filterTokenKind = iota
+	tokenAt
+	tokenDot
+	tokenLBracket
+	tokenRBracket
+	tokenLParen
+	tokenRParen
+	tokenOperator
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenRegex
+)
+
+// filterLexer splits a filter expression into tokens
+type filterLexer struct {
+	path string // whole JSONPath, for error columns
+	pos  int
+	end  int
+}
+
+func (l *filterLexer) errorf(pos int, format string, args ...any) error {
+	return &syntaxError{column: pos + 1, msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *filterLexer) next() (filterToken, error) {
+	for l.pos < l.end && (l.path[l.pos] == ' ' || l.path[l.pos] == '\t') {
+		l.pos++
+	}
+	if l.pos >= l.end {
+		return filterToken{kind: tokenEOF, pos: l.end}, nil
+	}
+
+	start := l.pos
+	ch := l.path[l.pos]
+	single := map[byte]filterTokenKind{'@': tokenAt, '.': tokenDot, '[': tokenLBracket, ']': tokenRBracket, '(': tokenLParen, ')': tokenRParen}
+	if kind, ok := single[ch]; ok {
+		l.pos++
+		return filterToken{kind: kind, text: string(ch), pos: start}, nil
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!"} {
+		if strings.HasPrefix(l.path[l.pos:l.end], op) {
+			l.pos += len(op)
+			return filterToken{kind: tokenOperator, text: op, pos: start}, nil
+		}
+	}
+
+	switch {
+	case ch == '\'' || ch == '"':
+		s, err := l.scanQuoted(ch)
+		if err != nil {
+			return filterToken{}, err
+		}
+		return filterToken{kind: tokenString, text: s, pos: start}, nil
+	case ch == '/':
+		return l.scanRegex()
+	case ch == '-' || (ch >= '0' && ch <= '9'):
+		l.pos++
+		for l.pos < l.end && strings.IndexByte("0123456789.eE+-", l.path[l.pos]) >= 0 {
+			l.pos++
+		}
+		text := l.path[start:l.pos]
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return filterToken{}, l.errorf(start, "invalid number %q", text)
+		}
+		return filterToken{kind: tokenNumber, text: text, value: f, pos: start}, nil
+	case isIdentChar(ch):
+		for l.pos < l.end && isIdentChar(l.path[l.pos]) {
+			l.pos++
+		}
+		return filterToken{kind: tokenIdent, text: l.path[start:l.pos], pos: start}, nil
+	}
+
+	return filterToken{}, l.errorf(start, "unexpected character %q", ch)
+}
+
+// scanQuoted reads a quoted string, where a backslash escapes the next character
+func (l *filterLexer) scanQuoted(quote byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < l.end {
+		ch := l.path[l.pos]
+		switch {
+		case ch == '\\' && l.pos+1 < l.end:
+			b.WriteByte(l.path[l.pos+1])
+			l.pos += 2
+		case ch == quote:
+			l.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+			l.pos++
+		}
+	}
+	return "", l.errorf(start, "unterminated string")
+}
+
+// scanRegex reads a /pattern/ literal with an optional i flag
+func (l *filterLexer) scanRegex() (filterToken, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < l.end {
+		ch := l.path[l.pos]
+		switch {
+		case ch == '\\' && l.pos+1 < l.end && l.path[l.pos+1] == '/':
+			b.WriteByte('/')
+			l.pos += 2
+		case ch == '/':
+			l.pos++
+			pattern := b.String()
+			if l.pos < l.end && l.path[l.pos] == 'i' {
+				pattern = "(?i)" + pattern
+				l.pos++
+			}
+			return filterToken{kind: tokenRegex, text: pattern, pos: start}, nil
+		default:
+			b.WriteByte(ch)
+			l.pos++
+		}
+	}
+	return filterToken{}, l.errorf(start, "unterminated regular expression")
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '-' || ch == '$' ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// filterParser is a recursive descent parser for filter expressions:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" ) operand ]
+//	operand    = "@" { "." ident | "[" string "]" } | string | number | true | false | null | regex
+type filterParser struct {
+	lexer *filterLexer
+	tok   filterToken
+}
+
+// parseFilterExpression parses the filter expression at path[start:end]
+func parseFilterExpression(path string, start, end int) (*FilterExpression, error) {
+	p := &filterParser{lexer: &filterLexer{path: path, pos: start, end: end}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenEOF {
+		return nil, p.lexer.errorf(start, "empty filter expression")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.lexer.errorf(p.tok.pos, "unexpected %q", p.tok.text)
+	}
+
+	return &FilterExpression{Source: strings.TrimSpace(path[start:end]), root: root}, nil
+}
+
+func (p *filterParser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenOperator && p.tok.text == "||" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenOperator && p.tok.text == "&&" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch {
+	case p.tok.kind == tokenOperator && p.tok.text == "!":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	case p.tok.kind == tokenLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRParen {
+			return nil, p.lexer.errorf(p.tok.pos, "expected ')'")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	leftPos := p.tok.pos
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenOperator || !isComparisonOperator(p.tok.text) {
+		ref, ok := left.(*referenceOperand)
+		if !ok {
+			return nil, p.lexer.errorf(leftPos, "expected a comparison or a field reference starting with '@'")
+		}
+		return &existsNode{ref: ref}, nil
+	}
+
+	op := p.tok.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	node := &comparisonNode{op: op, left: left}
+	if op == "=~" {
+		if p.tok.kind != tokenRegex && p.tok.kind != tokenString {
+			return nil, p.lexer.errorf(p.tok.pos, "expected a regular expression after =~")
+		}
+		pattern, err := regexp.Compile(p.tok.text)
+		if err != nil {
+			return nil, p.lexer.errorf(p.tok.pos, "invalid regular expression: %v", err)
+		}
+		node.pattern = pattern
+		return node, p.advance()
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	node.right = right
+	return node, nil
+}
+
+func (p *filterParser) parseOperand() (operand, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenAt:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return p.parseReference()
+	case tokenString:
+		return &literalOperand{value: tok.text}, p.advance()
+	case tokenNumber:
+		return &literalOperand{value: tok.value}, p.advance()
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalOperand{value: true}, p.advance()
+		case "false":
+			return &literalOperand{value: false}, p.advance()
+		case "null":
+			return &literalOperand{value: nil}, p.advance()
+		}
+		return nil, p.lexer.errorf(tok.pos, "unknown identifier %q; field references must start with '@'", tok.text)
+	case tokenEOF:
+		return nil, p.lexer.errorf(tok.pos, "unexpected end of filter expression")
+	}
+	return nil, p.lexer.errorf(tok.pos, "unexpected %q", tok.text)
+}
+
+// parseReference parses the keys following '@'
+func (p *filterParser) parseReference() (*referenceOperand, error) {
+	ref := &referenceOperand{}
+	for {
+		switch p.tok.kind {
+		case tokenDot:
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokenIdent {
+				return nil, p.lexer.errorf(p.tok.pos, "expected a field name after '.'")
+			}
+			ref.keys = append(ref.keys, p.tok.text)
+		case tokenLBracket:
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokenString {
+				return nil, p.lexer.errorf(p.tok.pos, "expected a quoted field name after '['")
+			}
+			ref.keys = append(ref.keys, p.tok.text)
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokenRBracket {
+				return nil, p.lexer.errorf(p.tok.pos, "expected ']'")
+			}
+		default:
+			return ref, nil
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isComparisonOperator(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package jsonpath
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+func mustParseFilter(t *testing.T, source string) *FilterExpression {
+	t.Helper()
+	filter, err := parseFilterExpression(source, 0, len(source))
+	require.NoError(t, err)
+	return filter
+}
+
+func TestFilterExpression_Matches(t *testing.T) {
+	element := map[string]any{
+		"name":     "build-image",
+		"replicas": float64(3),
+		"count":    2,
+		"enabled":  true,
+		"empty":    nil,
+		"container": map[string]any{
+			"image": "registry.example.com/app:1.2.3",
+			"resources": map[string]any{
+				"limits": map[string]any{"cpu": "500m"},
+			},
+		},
+		"app.kubernetes.io/name": "app",
+	}
+
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		// equality and quoting
+		{`@.name == 'build-image'`, true},
+		{`@.name == "build-image"`, true},
+		{`@.name=='build-image'`, true},
+		{`'build-image' == @.name`, true},
+		{`@.name != 'build-image'`, false},
+		{`@.name != 'other'`, true},
+		{`@.name == 'it\'s'`, false},
+		// nested references
+		{`@.container.image == 'registry.example.com/app:1.2.3'`, true},
+		{`@.container.resources.limits.cpu == '500m'`, true},
+		{`@['app.kubernetes.io/name'] == 'app'`, true},
+		{`@.container['image'] =~ /:1\.2\.3$/`, true},
+		// numbers, booleans and null
+		{`@.replicas == 3`, true},
+		{`@.count == 2.0`, true},
+		{`@.replicas == '3'`, true}, // string form of numbers matches for compatibility
+		{`@.replicas > 2`, true},
+		{`@.replicas >= 3`, true},
+		{`@.replicas < 3`, false},
+		{`@.replicas <= 3`, true},
+		{`@.count < -1`, false},
+		{`@.enabled == true`, true},
+		{`@.enabled == 'true'`, true},
+		{`@.empty == null`, true},
+		{`@.name < 'c'`, true}, // strings are ordered lexically
+		{`@.name > 3`, false},  // mismatched types never order
+		// missing fields
+		{`@.missing == 'x'`, false},
+		{`@.missing != 'x'`, true},
+		{`@.missing > 1`, false},
+		{`@.missing =~ /x/`, false},
+		// existence
+		{`@.container`, true},
+		{`@.container.command`, false},
+		{`!@.container.command`, true},
+		{`@.empty`, true},
+		// regular expressions
+		{`@.name =~ /^build-/`, true},
+		{`@.name =~ /^BUILD-/`, false},
+		{`@.name =~ /^BUILD-/i`, true},
+		{`@.name =~ '^build-.*e$'`, true},
+		{`@.container.image =~ /example\.com\/app/`, true},
+		{`@.replicas =~ /3/`, false}, // only strings match regular expressions
+		// boolean combinators
+		{`@.name == 'build-image' && @.replicas > 1`, true},
+		{`@.name == 'build-image' && @.replicas > 5`, false},
+		{`@.name == 'other' || @.replicas > 1`, true},
+		{`@.name == 'other' || @.replicas > 5`, false},
+		{`@.name == 'other' || @.replicas > 1 && @.enabled == false`, false}, // && binds tighter
+		{`(@.name == 'other' || @.replicas > 1) && @.enabled == true`, true},
+		{`!(@.name == 'other')`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mustParseFilter(t, tt.source).Matches(element))
+		})
+	}
+
+	t.Run("current element", func(t *testing.T) {
+		filter := mustParseFilter(t, `@ == '--verbose'`)
+		assert.True(t, filter.Matches("--verbose"))
+		assert.False(t, filter.Matches("--quiet"))
+		assert.False(t, filter.Matches(map[string]any{}))
+	})
+}
+
+func TestPathEvaluator_parseJSONPath_SyntaxErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		path          string
+		errorContains string
+	}{
+		{`$.templates[?(@.name = 'x')]`, "syntax error at column 22: unexpected character '='"},
+		{`$.templates[?(@.name == 'x)]`, "syntax error at column 25: unterminated string"},
+		{`$.templates[?(@.name == 'x'`, "syntax error at column 12: unterminated filter expression"},
+		{`$.templates[?(@.name == 'x')`, "syntax error at column 29: expected ']' after filter expression"},
+		{`$.templates[?()]`, "syntax error at column 15: empty filter expression"},
+		{`$.templates[?(@.name ==)]`, "syntax error at column 24: unexpected end of filter expression"},
+		{`$.templates[?(name == 'x')]`, `syntax error at column 15: unknown identifier "name"`},
+		{`$.templates[?(@.name == 'x' &&)]`, "syntax error at column 31: unexpected end of filter expression"},
+		{`$.templates[?(@.name == 'x' 'y')]`, `syntax error at column 29: unexpected "y"`},
+		{`$.templates[?(@. == 'x')]`, "syntax error at column 18: expected a field name after '.'"},
+		{`$.templates[?('x')]`, "syntax error at column 15: expected a comparison or a field reference starting with '@'"},
+		{`$.templates[?(@.name =~ /[/)]`, "syntax error at column 25: invalid regular expression"},
+		{`$.templates[?(@.name =~ 3)]`, "syntax error at column 25: expected a regular expression after =~"},
+		{`$.templates[?((@.name == 'x')]`, "syntax error at column 12: unterminated filter expression"},
+		{`$.templates[abc]`, `syntax error at column 13: invalid array index "abc"`},
+		{`$.templates[0`, "syntax error at column 12: unterminated '['"},
+		{`$[0]`, "syntax error at column 2: subscript must follow a field name"},
+		{`$x`, "syntax error at column 2: expected '.' or '['"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := evaluator.parseJSONPath(tt.path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorContains)
+		})
+	}
+}
+
+func TestPathEvaluator_parseJSONPath_Filters(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []PathSegment
+	}{
+		{
+			name: "brackets, dots and parentheses inside strings",
+			path: `$.templates[?(@.name == 'a]b.c)d' || @.name == "e)]")].image`,
+			expected: []PathSegment{
+				{Key: "templates", Filter: mustParseFilter(t, `@.name == 'a]b.c)d' || @.name == "e)]"`)},
+				{Key: "image"},
+			},
+		},
+		{
+			name: "nested parentheses and regular expression",
+			path: `$.templates[?((@.a == 1 || @.b == 2) && @.c =~ /^x(y|z)$/)]`,
+			expected: []PathSegment{
+				{Key: "templates", Filter: mustParseFilter(t, `(@.a == 1 || @.b == 2) && @.c =~ /^x(y|z)$/`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.parseJSONPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_FilterGrammar(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	newBase := func() *argoworkflowsv1alpha1.CronWorkflow {
+		return &argoworkflowsv1alpha1.CronWorkflow{
+			Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+				WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+					Templates: []argoworkflowsv1alpha1.Template{
+						{Name: "setup", Container: &corev1.Container{Image: "busybox:1.36"}},
+						{Name: "build", Container: &corev1.Container{Image: "golang:1.25"}},
+						{Name: "publish", Container: &corev1.Container{Image: "golang:1.25", Args: []string{"--push"}}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedIndexes []int
+	}{
+		{name: "nested field", path: `$.spec.workflowSpec.templates[?(@.container.image == "golang:1.25")].serviceAccountName`, expectedIndexes: []int{1, 2}},
+		{name: "not equal", path: `$.spec.workflowSpec.templates[?(@.name != 'setup')].serviceAccountName`, expectedIndexes: []int{1, 2}},
+		{name: "and", path: `$.spec.workflowSpec.templates[?(@.container.image =~ /^golang/ && @.container.args)].serviceAccountName`, expectedIndexes: []int{2}},
+		{name: "or", path: `$.spec.workflowSpec.templates[?(@.name == 'missing' || @.name == 'publish')].serviceAccountName`, expectedIndexes: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := *newBase()
+			require.NoError(t, evaluator.ApplyPaths(&cw, []config.PathValue{{Path: tt.path, Value: "sa"}}))
+			for i, template := range cw.Spec.WorkflowSpec.Templates {
+				if slices.Contains(tt.expectedIndexes, i) {
+					assert.Equal(t, "sa", template.ServiceAccountName)
+				} else {
+					assert.Empty(t, template.ServiceAccountName)
+				}
+			}
+		})
+	}
+
+	t.Run("syntax error names the column", func(t *testing.T) {
+		cw := *newBase()
+		err := evaluator.ApplyPaths(&cw, []config.PathValue{{Path: `$.spec.workflowSpec.templates[?(@.name = 'x')].name`, Value: "y"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "syntax error at column 40")
+	})
+}
@@ -0,0 +1,442 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// location is a place in the document matched by a JSONPath: either a key of a map, or an
+// element of the array at another location
+type location struct {
+	parent map[string]any
+	key    string
+	array  *location // non-nil if the location is an element of the array at *array
+	index  int
+}
+
+// get returns the value at the location and whether it exists
+func (l location) get() (any, bool) {
+	if l.array == nil {
+		value, exists := l.parent[l.key]
+		return value, exists
+	}
+	value, exists := l.array.get()
+	if !exists {
+		return nil, false
+	}
+	arr, ok := value.([]any)
+	if !ok || l.index >= len(arr) {
+		return nil, false
+	}
+	return arr[l.index], true
+}
+
+// set stores value at the location
+func (l location) set(value any) {
+	if l.array == nil {
+		l.parent[l.key] = value
+		return
+	}
+	arr, _ := l.array.get()
+	arr.([]any)[l.index] = value
+}
+
+// remove deletes the map key or array element at the location.
+// Nothing happens if the location no longer exists.
+func (l location) remove() {
+	if l.array == nil {
+		delete(l.parent, l.key)
+		return
+	}
+	value, exists := l.array.get()
+	arr, ok := value.([]any)
+	if !exists || !ok || l.index >= len(arr) {
+		return
+	}
+	l.array.set(removeIndex(arr, l.index))
+}
+
+// id identifies the location by the map it belongs to and the path of array indices below it,
+// so that two locations with the same id refer to the same place in the document
+func (l location) id() string {
+	if l.array == nil {
+		return fmt.Sprintf("%p/%s", l.parent, l.key)
+	}
+	return l.array.id() + "[" + strconv.Itoa(l.index) + "]"
+}
+
+// depth returns the number of arrays nested directly in each other that lead to the location
+func (l location) depth() int {
+	if l.array == nil {
+		return 0
+	}
+	return l.array.depth() + 1
+}
+
+// removeLocations removes every location, each of them once. Elements of the same array are
+// removed in descending index order so that removing one does not shift the indices of the rest,
+// and elements of nested arrays are removed before the elements of the arrays that contain them.
+func removeLocations(locations []location) {
+	seen := map[string]bool{}
+	var unique []location
+	for _, loc := range locations {
+		if id := loc.id(); !seen[id] {
+			seen[id] = true
+			unique = append(unique, loc)
+		}
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if a.depth() != b.depth() {
+			return a.depth() > b.depth()
+		}
+		if a.array == nil || b.array == nil {
+			return false
+		}
+		if aID, bID := a.array.id(), b.array.id(); aID != bID {
+			return aID < bID
+		}
+		return a.index > b.index
+	})
+
+	for _, loc := range unique {
+		loc.remove()
+	}
+}
+
+// resolveLocations returns every location matched by the path segments, in document order.
+// When create is true, missing map keys are created and array indices beyond the end extend the
+// array, so that a definite path always resolves to exactly one location. Wildcards, slices,
+// filters and recursive descent only match what already exists.
+// Type mismatches are errors while the path is definite; once a step has matched several
+// candidates, candidates of the wrong type are skipped instead.
+// If nothing matches, the returned error wraps errPathNotFound and names the step that matched nothing.
+func resolveLocations(target map[string]any, segments []PathSegment, create bool) ([]location, error) {
+	containers := []any{target}
+	definite := true
+	var locations []location
+
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		hasSubscript := segment.ArrayIndex != nil || segment.Filter != nil || segment.Wildcard || segment.Slice != nil
+
+		// Key step: find the keys named by the segment in the current containers. A chained
+		// subscript has no key and selects elements of the arrays matched by the previous segment.
+		keyMatches := locations
+		if !segment.Chained {
+			keyMatches = nil
+			for _, container := range containers {
+				keyMatches = append(keyMatches, matchKey(container, segment, create, last)...)
+			}
+		}
+		if segment.Recursive || segment.isKeyWildcard() {
+			definite = false
+		}
+
+		// Subscript step: select elements of the arrays found by the key step.
+		// Elements added to extend an array are left empty if the path ends or continues with
+		// another subscript, and are maps otherwise.
+		leaf := last || segments[i+1].Chained
+		locations = keyMatches
+		if hasSubscript {
+			locations = nil
+			for _, loc := range keyMatches {
+				elements, err := matchSubscript(loc, segment, formatSegments(segments[:i]), create, definite, leaf)
+				if err != nil {
+					return nil, err
+				}
+				locations = append(locations, elements...)
+			}
+			if segment.ArrayIndex == nil {
+				definite = false
+			}
+		}
+
+		if len(locations) == 0 {
+			return nil, fmt.Errorf("%w: %s matched nothing", errPathNotFound, formatSegments(segments[:i+1]))
+		}
+		if last {
+			break
+		}
+		if segments[i+1].Chained {
+			// The next subscript applies to the matched locations themselves
+			continue
+		}
+
+		// The matched values are the containers of the next key step
+		containers = nil
+		for _, loc := range locations {
+			value, _ := loc.get()
+			if value == nil && create && definite {
+				value = make(map[string]any)
+				loc.set(value)
+			}
+			if _, ok := value.(map[string]any); !ok && definite && !segments[i+1].Recursive {
+				return nil, fmt.Errorf("path segment %s is not a map, cannot navigate", formatSegments(segments[:i+1]))
+			}
+			containers = append(containers, value)
+		}
+	}
+
+	return locations, nil
+}
+
+// matchKey returns the locations of the segment's key in container.
+// Missing keys are created as maps (or arrays, for an index subscript) only for definite keys
+// that the path continues through; the final key is returned as-is so the caller can set it.
+func matchKey(container any, segment PathSegment, create, last bool) []location {
+	if segment.Recursive {
+		var locations []location
+		walkMaps(container, func(m map[string]any) {
+			locations = append(locations, keyLocations(m, segment.Key, segment.isKeyWildcard())...)
+		})
+		return locations
+	}
+
+	m, ok := container.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if segment.isKeyWildcard() {
+		return keyLocations(m, "", true)
+	}
+
+	if _, exists := m[segment.Key]; !exists {
+		switch {
+		case !create:
+			return nil
+		case segment.ArrayIndex != nil:
+			m[segment.Key] = []any{}
+		case segment.Filter != nil || segment.Wildcard || segment.Slice != nil:
+			// Wildcards, slices and filters never create, so there is nothing to match
+			return nil
+		case !last:
+			m[segment.Key] = make(map[string]any)
+		}
+	}
+	return []location{{parent: m, key: segment.Key}}
+}
+
+// keyLocations returns the location of key in m, or of every key in sorted order if all is true
+func keyLocations(m map[string]any, key string, all bool) []location {
+	if !all {
+		if _, exists := m[key]; !exists {
+			return nil
+		}
+		return []location{{parent: m, key: key}}
+	}
+
+	locations := make([]location, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		locations = append(locations, location{parent: m, key: k})
+	}
+	return locations
+}
+
+// walkMaps calls fn for every map in value and its descendants, in document order
+func walkMaps(value any, fn func(map[string]any)) {
+	switch v := value.(type) {
+	case map[string]any:
+		fn(v)
+		for _, k := range sortedKeys(v) {
+			walkMaps(v[k], fn)
+		}
+	case []any:
+		for _, element := range v {
+			walkMaps(element, fn)
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchSubscript returns the element locations selected by the segment's subscript in the array
+// stored at loc. prefix is the path up to the segment, for error messages.
+// When an index extends the array, the new elements are nil if leaf is true and maps otherwise.
+func matchSubscript(loc location, segment PathSegment, prefix string, create, definite, leaf bool) ([]location, error) {
+	value, exists := loc.get()
+	if value == nil && segment.ArrayIndex != nil && create {
+		value = []any{}
+		loc.set(value)
+	} else if !exists || value == nil {
+		return nil, nil
+	}
+
+	arr, ok := value.([]any)
+	if !ok {
+		if m, isMap := value.(map[string]any); isMap && segment.Wildcard {
+			// [*] on a map selects every value, like .*
+			return keyLocations(m, "", true), nil
+		}
+		if definite {
+			return nil, fmt.Errorf("%s exists but is not an array", subscriptTarget(loc, segment, prefix))
+		}
+		return nil, nil
+	}
+
+	var indices []int
+	switch {
+	case segment.ArrayIndex != nil:
+		index := *segment.ArrayIndex
+		actualIndex := index
+		if index < 0 {
+			if !create && len(arr)+index < 0 {
+				return nil, nil
+			}
+			if len(arr) == 0 {
+				return nil, fmt.Errorf("cannot use negative index %d on empty array", index)
+			}
+			actualIndex = len(arr) + index // index is negative, so this is len(arr) - abs(index)
+			if actualIndex < 0 {
+				return nil, fmt.Errorf("negative index %d is out of bounds for array of length %d", index, len(arr))
+			}
+		}
+		if actualIndex >= len(arr) {
+			if !create {
+				return nil, nil
+			}
+			// Extend the array; elements the path continues through become maps
+			for len(arr) <= actualIndex {
+				if leaf {
+					arr = append(arr, nil)
+				} else {
+					arr = append(arr, make(map[string]any))
+				}
+			}
+			loc.set(arr)
+		}
+		indices = []int{actualIndex}
+	case segment.Wildcard:
+		for i := range arr {
+			indices = append(indices, i)
+		}
+	case segment.Slice != nil:
+		indices = segment.Slice.indices(len(arr))
+	case segment.Filter != nil:
+		for i, element := range arr {
+			if segment.Filter.Matches(element) {
+				indices = append(indices, i)
+			}
+		}
+	}
+
+	locations := make([]location, 0, len(indices))
+	for _, i := range indices {
+		locations = append(locations, location{array: &loc, index: i})
+	}
+	return locations, nil
+}
+
+// subscriptTarget describes the value a subscript is applied to, for error messages
+func subscriptTarget(loc location, segment PathSegment, prefix string) string {
+	if segment.Chained || loc.array != nil {
+		return "element " + prefix
+	}
+	return "key " + loc.key
+}
+
+// SliceExpression represents an array slice [start:end:step] with Python semantics.
+// Start and End are nil when omitted; negative values count from the end.
+type SliceExpression struct {
+	Start *int
+	End   *int
+	Step  int // defaults to 1
+}
+
+// indices returns the array indices selected by the slice for an array of the given length
+func (s *SliceExpression) indices(length int) []int {
+	step := s.Step
+	if step == 0 {
+		step = 1
+	}
+	normalize := func(bound *int, fallback int) int {
+		if bound == nil {
+			return fallback
+		}
+		i := *bound
+		if i < 0 {
+			i += length
+		}
+		return min(max(i, -1), length)
+	}
+
+	var indices []int
+	if step > 0 {
+		start := max(normalize(s.Start, 0), 0)
+		end := normalize(s.End, length)
+		for i := start; i < end; i += step {
+			indices = append(indices, i)
+		}
+	} else {
+		start := min(normalize(s.Start, length-1), length-1)
+		end := normalize(s.End, -1)
+		for i := start; i > end; i += step {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// String returns the slice in JSONPath notation
+func (s *SliceExpression) String() string {
+	bound := func(i *int) string {
+		if i == nil {
+			return ""
+		}
+		return strconv.Itoa(*i)
+	}
+	if s.Step != 0 && s.Step != 1 {
+		return fmt.Sprintf("[%s:%s:%d]", bound(s.Start), bound(s.End), s.Step)
+	}
+	return fmt.Sprintf("[%s:%s]", bound(s.Start), bound(s.End))
+}
+
+// formatQuotedKey renders a key in ['key'] notation, escaping quotes and backslashes
+func formatQuotedKey(key string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(key)
+	return "['" + escaped + "']"
+}
+
+// formatSegments renders path segments back into JSONPath notation
+func formatSegments(segments []PathSegment) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range segments {
+		switch {
+		case segment.Chained:
+		case segment.Recursive:
+			b.WriteString("..")
+		case !segment.Quoted:
+			b.WriteString(".")
+		}
+		if segment.Chained {
+			// Only the subscript is written
+		} else if segment.Quoted {
+			b.WriteString(formatQuotedKey(segment.Key))
+		} else {
+			b.WriteString(segment.Key)
+		}
+		switch {
+		case segment.ArrayIndex != nil:
+			fmt.Fprintf(&b, "[%d]", *segment.ArrayIndex)
+		case segment.Wildcard:
+			b.WriteString("[*]")
+		case segment.Slice != nil:
+			b.WriteString(segment.Slice.String())
+		case segment.Filter != nil:
+			b.WriteString(segment.Filter.String())
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,561 @@
+package jsonpath
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+func newMultiTargetBaseCronWorkflow() *argoworkflowsv1alpha1.CronWorkflow {
+	return &argoworkflowsv1alpha1.CronWorkflow{
+		Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+			WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+				Templates: []argoworkflowsv1alpha1.Template{
+					{Name: "setup", Container: &corev1.Container{Image: "busybox", Env: []corev1.EnvVar{{Name: "A"}, {Name: "B"}}}},
+					{Name: "build", Container: &corev1.Container{Image: "golang"}},
+					{Name: "steps", Steps: []argoworkflowsv1alpha1.ParallelSteps{{Steps: []argoworkflowsv1alpha1.WorkflowStep{{Name: "s1"}}}}},
+					{Name: "publish", Container: &corev1.Container{Image: "golang", Env: []corev1.EnvVar{{Name: "C"}}}},
+				},
+			},
+		},
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_MultiTarget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+	templateNames := func(cw *argoworkflowsv1alpha1.CronWorkflow) []string {
+		var names []string
+		for _, template := range cw.Spec.WorkflowSpec.Templates {
+			names = append(names, template.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name          string
+		paths         []config.PathValue
+		errorContains string
+		validator     func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "wildcard sets a field on every element",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[*].activeDeadlineSeconds", Value: 600},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				for _, template := range cw.Spec.WorkflowSpec.Templates {
+					require.NotNil(t, template.ActiveDeadlineSeconds)
+					assert.Equal(t, "600", template.ActiveDeadlineSeconds.String())
+				}
+			},
+		},
+		{
+			name: "wildcard creates missing maps under every element",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[*].metadata.labels.team", Value: "platform"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				for _, template := range cw.Spec.WorkflowSpec.Templates {
+					assert.Equal(t, map[string]string{"team": "platform"}, template.Metadata.Labels)
+				}
+			},
+		},
+		{
+			name: "recursive descent sets a field in every container",
+			paths: []config.PathValue{
+				{Path: "$..container.imagePullPolicy", Value: "Always"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				templates := cw.Spec.WorkflowSpec.Templates
+				assert.Equal(t, corev1.PullAlways, templates[0].Container.ImagePullPolicy)
+				assert.Equal(t, corev1.PullAlways, templates[1].Container.ImagePullPolicy)
+				assert.Nil(t, templates[2].Container)
+				assert.Equal(t, corev1.PullAlways, templates[3].Container.ImagePullPolicy)
+			},
+		},
+		{
+			name: "recursive descent with filter",
+			paths: []config.PathValue{
+				{Path: "$..templates[?(@.container.image == 'golang')].container.image", Value: "golang:1.25"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				templates := cw.Spec.WorkflowSpec.Templates
+				assert.Equal(t, "busybox", templates[0].Container.Image)
+				assert.Equal(t, "golang:1.25", templates[1].Container.Image)
+				assert.Equal(t, "golang:1.25", templates[3].Container.Image)
+			},
+		},
+		{
+			name: "slice selects a range",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[1:3].serviceAccountName", Value: "sa"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				templates := cw.Spec.WorkflowSpec.Templates
+				assert.Empty(t, templates[0].ServiceAccountName)
+				assert.Equal(t, "sa", templates[1].ServiceAccountName)
+				assert.Equal(t, "sa", templates[2].ServiceAccountName)
+				assert.Empty(t, templates[3].ServiceAccountName)
+			},
+		},
+		{
+			name: "negative slice selects from the end",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[-1:].serviceAccountName", Value: "sa"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Empty(t, cw.Spec.WorkflowSpec.Templates[2].ServiceAccountName)
+				assert.Equal(t, "sa", cw.Spec.WorkflowSpec.Templates[3].ServiceAccountName)
+			},
+		},
+		{
+			name: "key wildcard on labels",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Value: map[string]any{"a": "1", "b": "2"}},
+				{Path: "$.metadata.labels.*", Value: "x"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"a": "x", "b": "x"}, cw.Labels)
+			},
+		},
+		{
+			name: "delete every match of a filter",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.container.image == 'golang')]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"setup", "steps"}, templateNames(cw))
+			},
+		},
+		{
+			name: "delete every element with a wildcard",
+			paths: []config.PathValue{
+				{Path: "$..env[*]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Empty(t, cw.Spec.WorkflowSpec.Templates[0].Container.Env)
+				assert.Empty(t, cw.Spec.WorkflowSpec.Templates[3].Container.Env)
+			},
+		},
+		{
+			name: "delete with a stepped slice",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[::2]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"build", "publish"}, templateNames(cw))
+			},
+		},
+		{
+			name: "delete with a negative step slice",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[::-1]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Empty(t, cw.Spec.WorkflowSpec.Templates)
+			},
+		},
+		{
+			name: "delete with a bounded negative step slice",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[2:0:-1]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"setup", "publish"}, templateNames(cw))
+			},
+		},
+		{
+			name: "delete elements of nested arrays",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[2].steps[0][1].name", Value: "s2"},
+				{Path: "$.spec.workflowSpec.templates[2].steps[1][0].name", Value: "s3"},
+				{Path: "$.spec.workflowSpec.templates[2].steps[::-1][0]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				steps := cw.Spec.WorkflowSpec.Templates[2].Steps
+				require.Len(t, steps, 2)
+				require.Len(t, steps[0].Steps, 1)
+				assert.Equal(t, "s2", steps[0].Steps[0].Name)
+				assert.Empty(t, steps[1].Steps)
+			},
+		},
+		{
+			name: "append to every matched array",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.container)].container.env", Op: config.PathOpAppend, Value: map[string]any{"name": "EXTRA"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				templates := cw.Spec.WorkflowSpec.Templates
+				assert.Len(t, templates[0].Container.Env, 3)
+				assert.Len(t, templates[1].Container.Env, 1)
+				assert.Len(t, templates[3].Container.Env, 2)
+				assert.Equal(t, "EXTRA", templates[1].Container.Env[0].Name)
+			},
+		},
+		{
+			name: "merge into every match",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[*]", Op: config.PathOpMerge, Value: map[string]any{"serviceAccountName": "sa"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"setup", "build", "steps", "publish"}, templateNames(cw))
+				for _, template := range cw.Spec.WorkflowSpec.Templates {
+					assert.Equal(t, "sa", template.ServiceAccountName)
+				}
+			},
+		},
+		{
+			name: "matches receive independent copies of the value",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[*].metadata", Value: map[string]any{"labels": map[string]any{"shared": "no"}}},
+				{Path: "$.spec.workflowSpec.templates[0].metadata.labels.only-first", Value: "yes"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "yes", cw.Spec.WorkflowSpec.Templates[0].Metadata.Labels["only-first"])
+				assert.NotContains(t, cw.Spec.WorkflowSpec.Templates[1].Metadata.Labels, "only-first")
+			},
+		},
+		{
+			name: "zero matches is an error naming the step",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'missing')].container.image", Value: "x"},
+			},
+			errorContains: "$.spec.workflowSpec.templates[?(@.name == 'missing')] matched nothing",
+		},
+		{
+			name: "zero matches from recursive descent",
+			paths: []config.PathValue{
+				{Path: "$..sidecars[*].image", Value: "x"},
+			},
+			errorContains: "$..sidecars[*] matched nothing",
+		},
+		{
+			name: "optional zero matches is skipped",
+			paths: []config.PathValue{
+				{Path: "$..sidecars[*].image", Value: "x", Optional: true},
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'missing')]", Op: config.PathOpAppend, Value: "x", Optional: true},
+				{Path: "$.spec.workflowSpec.templates[5:]", Op: config.PathOpMerge, Value: map[string]any{}, Optional: true},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, newMultiTargetBaseCronWorkflow(), cw)
+			},
+		},
+		{
+			name: "chained subscripts reach into nested arrays",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[2].steps[0][0].template", Value: "setup"},
+				{Path: "$.spec.workflowSpec.templates[2].steps[0][1].name", Value: "s2"},
+				{Path: "$.spec.workflowSpec.templates[2].steps[1][0].name", Value: "s3"},
+				{Path: "$.spec.workflowSpec.templates[2].steps[*][*].arguments.parameters", Value: []any{}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				steps := cw.Spec.WorkflowSpec.Templates[2].Steps
+				require.Len(t, steps, 2)
+				require.Len(t, steps[0].Steps, 2)
+				assert.Equal(t, "s1", steps[0].Steps[0].Name)
+				assert.Equal(t, "setup", steps[0].Steps[0].Template)
+				assert.Equal(t, "s2", steps[0].Steps[1].Name)
+				require.Len(t, steps[1].Steps, 1)
+				assert.Equal(t, "s3", steps[1].Steps[0].Name)
+			},
+		},
+		{
+			name: "chained subscripts create nested arrays",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].steps[0][0].name", Value: "first"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				steps := cw.Spec.WorkflowSpec.Templates[0].Steps
+				require.Len(t, steps, 1)
+				require.Len(t, steps[0].Steps, 1)
+				assert.Equal(t, "first", steps[0].Steps[0].Name)
+			},
+		},
+		{
+			name: "chained subscript deletes from a nested array",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[2].steps[0][0]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				steps := cw.Spec.WorkflowSpec.Templates[2].Steps
+				require.Len(t, steps, 1)
+				assert.Empty(t, steps[0].Steps)
+			},
+		},
+		{
+			name: "chained subscript on an element that is not an array",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0][0]", Value: "x"},
+			},
+			errorContains: "element $.spec.workflowSpec.templates[0] exists but is not an array",
+		},
+		{
+			name: "wildcard skips elements of the wrong type",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[*].container.env[*].value", Value: "v"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				templates := cw.Spec.WorkflowSpec.Templates
+				assert.Equal(t, "v", templates[0].Container.Env[0].Value)
+				assert.Equal(t, "v", templates[0].Container.Env[1].Value)
+				assert.Equal(t, "v", templates[3].Container.Env[0].Value)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newMultiTargetBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPaths(&cw, tt.paths)
+
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			assert.Equal(t, newMultiTargetBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestPathEvaluator_parseJSONPath_MultiTarget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name          string
+		path          string
+		expected      []PathSegment
+		errorContains string
+	}{
+		{
+			name: "wildcard",
+			path: "$.spec.templates[*].name",
+			expected: []PathSegment{
+				{Key: "spec"},
+				{Key: "templates", Wildcard: true},
+				{Key: "name"},
+			},
+		},
+		{
+			name: "key wildcard",
+			path: "$.metadata.labels.*",
+			expected: []PathSegment{
+				{Key: "metadata"},
+				{Key: "labels"},
+				{Key: "*"},
+			},
+		},
+		{
+			name: "recursive descent",
+			path: "$..container.image",
+			expected: []PathSegment{
+				{Key: "container", Recursive: true},
+				{Key: "image"},
+			},
+		},
+		{
+			name: "recursive descent in the middle",
+			path: "$.spec..env[0]",
+			expected: []PathSegment{
+				{Key: "spec"},
+				{Key: "env", Recursive: true, ArrayIndex: intPtr(0)},
+			},
+		},
+		{
+			name: "slice",
+			path: "$.items[0:3]",
+			expected: []PathSegment{
+				{Key: "items", Slice: &SliceExpression{Start: intPtr(0), End: intPtr(3), Step: 1}},
+			},
+		},
+		{
+			name: "open slice with step",
+			path: "$.items[-2::-1]",
+			expected: []PathSegment{
+				{Key: "items", Slice: &SliceExpression{Start: intPtr(-2), Step: -1}},
+			},
+		},
+		{
+			name: "chained subscripts",
+			path: "$.steps[0][-1].name",
+			expected: []PathSegment{
+				{Key: "steps", ArrayIndex: intPtr(0)},
+				{Chained: true, ArrayIndex: intPtr(-1), IsNegative: true},
+				{Key: "name"},
+			},
+		},
+		{
+			name: "wildcard and index",
+			path: "$.items[*][0]",
+			expected: []PathSegment{
+				{Key: "items", Wildcard: true},
+				{Chained: true, ArrayIndex: intPtr(0)},
+			},
+		},
+		{name: "missing key after recursive descent", path: "$..", errorContains: "syntax error at column 4: expected a field name after '..'"},
+		{name: "zero step", path: "$.items[::0]", errorContains: "syntax error at column 9: invalid slice \"::0\": step cannot be zero"},
+		{name: "invalid slice bound", path: "$.items[a:2]", errorContains: "syntax error at column 9: invalid slice \"a:2\": invalid syntax"},
+		{name: "too many colons", path: "$.items[1:2:3:4]", errorContains: "too many ':'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.parseJSONPath(tt.path)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestSliceExpression_indices(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name     string
+		slice    SliceExpression
+		length   int
+		expected []int
+	}{
+		{name: "start and end", slice: SliceExpression{Start: intPtr(1), End: intPtr(3)}, length: 5, expected: []int{1, 2}},
+		{name: "open end", slice: SliceExpression{Start: intPtr(3)}, length: 5, expected: []int{3, 4}},
+		{name: "open start", slice: SliceExpression{End: intPtr(2)}, length: 5, expected: []int{0, 1}},
+		{name: "negative bounds", slice: SliceExpression{Start: intPtr(-3), End: intPtr(-1)}, length: 5, expected: []int{2, 3}},
+		{name: "step", slice: SliceExpression{Step: 2}, length: 5, expected: []int{0, 2, 4}},
+		{name: "reverse", slice: SliceExpression{Step: -1}, length: 3, expected: []int{2, 1, 0}},
+		{name: "reverse with bounds", slice: SliceExpression{Start: intPtr(3), End: intPtr(0), Step: -2}, length: 5, expected: []int{3, 1}},
+		{name: "bounds beyond length are clamped", slice: SliceExpression{Start: intPtr(-10), End: intPtr(10)}, length: 3, expected: []int{0, 1, 2}},
+		{name: "empty range", slice: SliceExpression{Start: intPtr(3), End: intPtr(1)}, length: 5, expected: nil},
+		{name: "empty array", slice: SliceExpression{}, length: 0, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.slice.indices(tt.length))
+		})
+	}
+}
+
+func TestRemoveLocations(t *testing.T) {
+	newDocument := func() map[string]any {
+		return map[string]any{
+			"items":  []any{"a", "b", "c", "d"},
+			"nested": []any{[]any{"x", "y"}, []any{"z"}},
+			"keep":   "yes",
+		}
+	}
+	element := func(doc map[string]any, key string, indices ...int) location {
+		loc := location{parent: doc, key: key}
+		for _, index := range indices {
+			array := loc
+			loc = location{array: &array, index: index}
+		}
+		return loc
+	}
+
+	tests := []struct {
+		name      string
+		locations func(doc map[string]any) []location
+		expected  map[string]any
+	}{
+		{
+			name: "overlapping matches are removed once",
+			locations: func(doc map[string]any) []location {
+				return []location{element(doc, "items", 1), element(doc, "items", 2), element(doc, "items", 1)}
+			},
+			expected: map[string]any{
+				"items":  []any{"a", "d"},
+				"nested": []any{[]any{"x", "y"}, []any{"z"}},
+				"keep":   "yes",
+			},
+		},
+		{
+			name: "ascending and descending orders give the same result",
+			locations: func(doc map[string]any) []location {
+				return []location{element(doc, "items", 3), element(doc, "items", 0), element(doc, "items", 2)}
+			},
+			expected: map[string]any{
+				"items":  []any{"b"},
+				"nested": []any{[]any{"x", "y"}, []any{"z"}},
+				"keep":   "yes",
+			},
+		},
+		{
+			name: "elements inside removed elements",
+			locations: func(doc map[string]any) []location {
+				return []location{element(doc, "nested", 0), element(doc, "nested", 1, 0), element(doc, "nested", 0, 1)}
+			},
+			expected: map[string]any{
+				"items":  []any{"a", "b", "c", "d"},
+				"nested": []any{[]any{}},
+				"keep":   "yes",
+			},
+		},
+		{
+			name: "elements of a removed key",
+			locations: func(doc map[string]any) []location {
+				return []location{element(doc, "items"), element(doc, "items", 0)}
+			},
+			expected: map[string]any{
+				"nested": []any{[]any{"x", "y"}, []any{"z"}},
+				"keep":   "yes",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newDocument()
+			removeLocations(tt.locations(doc))
+			assert.Equal(t, tt.expected, doc)
+		})
+	}
+}
+
+func TestFormatSegments(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	paths := []string{
+		"$",
+		"$.spec.templates[0].name",
+		"$.spec.templates[-1]",
+		"$.spec.templates[*].container.*",
+		"$..container.image",
+		"$.items[1:3]",
+		"$.items[::-1]",
+		"$.spec.templates[?(@.name == 'main')].container",
+		"$.metadata.labels['app.kubernetes.io/name']",
+		"$..['a.b'][0]",
+		"$.spec.templates[0].steps[0][-1].name",
+		"$.items[*][1:3]",
+		`$['it\'s']['C:\\temp']`,
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			segments, err := evaluator.parseJSONPath(path)
+			require.NoError(t, err)
+			assert.Equal(t, path, formatSegments(segments))
+		})
+	}
+}
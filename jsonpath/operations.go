@@ -0,0 +1,197 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+// errPathNotFound is returned when the target of an operation does not exist
+var errPathNotFound = errors.New("path not found")
+
+// deleteAtPath removes every map key or array element matched by the JSONPath
+func (pe *PathEvaluator) deleteAtPath(target map[string]any, path string) error {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSONPath: %w", err)
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("cannot delete the document root")
+	}
+
+	locations, err := resolveLocations(target, segments, false)
+	if err != nil {
+		return err
+	}
+
+	removeLocations(locations)
+	return nil
+}
+
+// replaceAtPath replaces every match of pattern in the strings matched by the JSONPath.
+// replacement may refer to capture groups as $1 or ${name}. It returns the number of strings that changed.
+func (pe *PathEvaluator) replaceAtPath(target map[string]any, path string, pattern *regexp.Regexp, replacement string) (int, error) {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse JSONPath: %w", err)
+	}
+	if len(segments) == 0 {
+		return 0, fmt.Errorf("target of op replace is not a string")
+	}
+
+	locations, err := resolveLocations(target, segments, false)
+	if err != nil {
+		return 0, err
+	}
+
+	replaced := 0
+	for _, loc := range locations {
+		value, _ := loc.get()
+		s, ok := value.(string)
+		if !ok {
+			return 0, fmt.Errorf("target of op replace is not a string, got %s", jsonTypeName(value))
+		}
+		if result := pattern.ReplaceAllString(s, replacement); result != s {
+			loc.set(result)
+			replaced++
+		}
+	}
+	return replaced, nil
+}
+
+// jsonTypeName returns the JSON type name of a decoded JSON value
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// insertAtPath adds value to the array at the JSONPath according to op.
+// Missing intermediate maps and the array itself are created, like assignments do.
+func (pe *PathEvaluator) insertAtPath(target map[string]any, path string, op config.PathOp, index *int, value any) error {
+	return pe.updateAtPath(target, path, func(existing any) (any, error) {
+		var arr []any
+		if existing != nil {
+			var ok bool
+			arr, ok = existing.([]any)
+			if !ok {
+				return nil, fmt.Errorf("target of op %s is not an array", op)
+			}
+		}
+		return insertElement(arr, op, index, deepCopy(value))
+	})
+}
+
+// mergeAtPath deep-merges value into the value at the JSONPath following RFC 7386 JSON Merge Patch
+func (pe *PathEvaluator) mergeAtPath(target map[string]any, path string, value any) error {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSONPath: %w", err)
+	}
+	if len(segments) == 0 {
+		patch, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("only a map can be merged into the document root")
+		}
+		// The root map is merged in place
+		mergePatch(target, patch)
+		return nil
+	}
+
+	return pe.updateAtPath(target, path, func(existing any) (any, error) {
+		return mergePatch(existing, deepCopy(value)), nil
+	})
+}
+
+// mergePatch applies an RFC 7386 merge patch to target and returns the result.
+// Maps are merged recursively (in place when target is a map), null values delete keys and
+// any other value replaces the target.
+func mergePatch(target any, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]any)
+	if !ok {
+		targetMap = make(map[string]any)
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = mergePatch(targetMap[key], value)
+	}
+	return targetMap
+}
+
+// updateAtPath replaces the value at every location matched by the JSONPath with the result of
+// update, which receives the current value (nil if absent). Missing maps along a definite path are
+// created, like assignments do.
+func (pe *PathEvaluator) updateAtPath(target map[string]any, path string, update func(existing any) (any, error)) error {
+	segments, err := pe.parseJSONPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSONPath: %w", err)
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("cannot update the document root")
+	}
+
+	locations, err := resolveLocations(target, segments, true)
+	if err != nil {
+		return err
+	}
+	for _, loc := range locations {
+		existing, _ := loc.get()
+		updated, err := update(existing)
+		if err != nil {
+			return err
+		}
+		loc.set(updated)
+	}
+	return nil
+}
+
+// insertElement returns a new slice with value added according to op
+func insertElement(arr []any, op config.PathOp, index *int, value any) ([]any, error) {
+	position := len(arr)
+	switch op {
+	case config.PathOpPrepend:
+		position = 0
+	case config.PathOpInsert:
+		position = *index
+		if position < 0 {
+			position = len(arr) + position
+		}
+		if position < 0 || position > len(arr) {
+			return nil, fmt.Errorf("insert index %d is out of bounds for array of length %d", *index, len(arr))
+		}
+	}
+
+	result := make([]any, 0, len(arr)+1)
+	result = append(result, arr[:position]...)
+	result = append(result, value)
+	return append(result, arr[position:]...), nil
+}
+
+// removeIndex returns a new slice without the element at index i
+func removeIndex(arr []any, i int) []any {
+	result := make([]any, 0, len(arr)-1)
+	result = append(result, arr[:i]...)
+	return append(result, arr[i+1:]...)
+}
@@ -0,0 +1,582 @@
+package jsonpath
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+func newOperationsBaseCronWorkflow() *argoworkflowsv1alpha1.CronWorkflow {
+	return &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "base",
+			Labels: map[string]string{
+				"app":  "base",
+				"team": "platform",
+			},
+		},
+		Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+			Schedule: "0 0 * * *",
+			WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+				Templates: []argoworkflowsv1alpha1.Template{
+					{
+						Name: "main",
+						Container: &corev1.Container{
+							Image: "busybox",
+							Env: []corev1.EnvVar{
+								{Name: "FIRST", Value: "1"},
+								{Name: "SECOND", Value: "2"},
+								{Name: "THIRD", Value: "3"},
+							},
+						},
+					},
+					{Name: "cleanup"},
+				},
+			},
+		},
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_Delete(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name      string
+		paths     []config.PathValue
+		expectErr bool
+		validator func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "delete map key",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.team", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app": "base"}, cw.Labels)
+			},
+		},
+		{
+			name: "delete whole field",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Nil(t, cw.Labels)
+			},
+		},
+		{
+			name: "delete list element by index",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env[1]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				env := cw.Spec.WorkflowSpec.Templates[0].Container.Env
+				require.Len(t, env, 2)
+				assert.Equal(t, "FIRST", env[0].Name)
+				assert.Equal(t, "THIRD", env[1].Name)
+			},
+		},
+		{
+			name: "delete list element by negative index",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[-1]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				require.Len(t, cw.Spec.WorkflowSpec.Templates, 1)
+				assert.Equal(t, "main", cw.Spec.WorkflowSpec.Templates[0].Name)
+			},
+		},
+		{
+			name: "delete list element by filter",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'main')].container.env[?(@.name == 'FIRST')]", Op: config.PathOpDelete},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				env := cw.Spec.WorkflowSpec.Templates[0].Container.Env
+				require.Len(t, env, 2)
+				assert.Equal(t, "SECOND", env[0].Name)
+			},
+		},
+		{
+			name: "delete then set in the same value",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Op: config.PathOpDelete},
+				{Path: "$.metadata.labels.fresh", Value: "yes"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"fresh": "yes"}, cw.Labels)
+			},
+		},
+		{
+			name: "missing key is an error",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.missing", Op: config.PathOpDelete},
+			},
+			expectErr: true,
+		},
+		{
+			name: "missing intermediate key is an error",
+			paths: []config.PathValue{
+				{Path: "$.metadata.annotations.missing", Op: config.PathOpDelete},
+			},
+			expectErr: true,
+		},
+		{
+			name: "index out of bounds is an error",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[5]", Op: config.PathOpDelete},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unmatched filter is an error",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'missing')]", Op: config.PathOpDelete},
+			},
+			expectErr: true,
+		},
+		{
+			name: "optional missing key is ignored",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.missing", Op: config.PathOpDelete, Optional: true},
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'missing')]", Op: config.PathOpDelete, Optional: true},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Len(t, cw.Labels, 2)
+				assert.Len(t, cw.Spec.WorkflowSpec.Templates, 2)
+			},
+		},
+		{
+			name: "optional does not hide type mismatches",
+			paths: []config.PathValue{
+				{Path: "$.metadata.name.nested", Op: config.PathOpDelete, Optional: true},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newOperationsBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPaths(&cw, tt.paths)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			// The base CronWorkflow must never be modified
+			assert.Equal(t, newOperationsBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_DoesNotLeakBetweenCopies(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+	base := newOperationsBaseCronWorkflow()
+
+	first := *base
+	require.NoError(t, evaluator.ApplyPaths(&first, []config.PathValue{
+		{Path: "$.metadata.labels.only-first", Value: "yes"},
+	}))
+
+	second := *base
+	require.NoError(t, evaluator.ApplyPaths(&second, []config.PathValue{
+		{Path: "$.metadata.name", Value: "second"},
+	}))
+
+	assert.Equal(t, "yes", first.Labels["only-first"])
+	assert.NotContains(t, second.Labels, "only-first")
+	assert.NotContains(t, base.Labels, "only-first")
+}
+
+func TestPathEvaluator_ApplyPaths_ArrayOps(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+	index := func(i int) *int { return &i }
+	envNames := func(cw *argoworkflowsv1alpha1.CronWorkflow) []string {
+		var names []string
+		for _, env := range cw.Spec.WorkflowSpec.Templates[0].Container.Env {
+			names = append(names, env.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name      string
+		paths     []config.PathValue
+		expectErr bool
+		validator func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "append map element",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env", Op: config.PathOpAppend, Value: map[string]any{"name": "EXTRA", "value": "x"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"FIRST", "SECOND", "THIRD", "EXTRA"}, envNames(cw))
+				assert.Equal(t, "x", cw.Spec.WorkflowSpec.Templates[0].Container.Env[3].Value)
+			},
+		},
+		{
+			name: "prepend element",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'main')].container.env", Op: config.PathOpPrepend, Value: map[string]any{"name": "ZERO"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"ZERO", "FIRST", "SECOND", "THIRD"}, envNames(cw))
+			},
+		},
+		{
+			name: "insert at index",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env", Op: config.PathOpInsert, Index: index(1), Value: map[string]any{"name": "INSERTED"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"FIRST", "INSERTED", "SECOND", "THIRD"}, envNames(cw))
+			},
+		},
+		{
+			name: "insert at negative index",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env", Op: config.PathOpInsert, Index: index(-1), Value: map[string]any{"name": "INSERTED"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"FIRST", "SECOND", "INSERTED", "THIRD"}, envNames(cw))
+			},
+		},
+		{
+			name: "insert at length appends",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env", Op: config.PathOpInsert, Index: index(3), Value: map[string]any{"name": "LAST"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, []string{"FIRST", "SECOND", "THIRD", "LAST"}, envNames(cw))
+			},
+		},
+		{
+			name: "append creates missing array",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.arguments.parameters", Op: config.PathOpAppend, Value: map[string]any{"name": "env", "value": "prod"}},
+				{Path: "$.spec.workflowSpec.templates[0].container.args", Op: config.PathOpAppend, Value: "--verbose"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				require.Len(t, cw.Spec.WorkflowSpec.Arguments.Parameters, 1)
+				assert.Equal(t, "env", cw.Spec.WorkflowSpec.Arguments.Parameters[0].Name)
+				assert.Equal(t, []string{"--verbose"}, cw.Spec.WorkflowSpec.Templates[0].Container.Args)
+			},
+		},
+		{
+			name: "append string value is converted",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates", Op: config.PathOpAppend, Value: `{"name": "sidecar"}`},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				require.Len(t, cw.Spec.WorkflowSpec.Templates, 3)
+				assert.Equal(t, "sidecar", cw.Spec.WorkflowSpec.Templates[2].Name)
+			},
+		},
+		{
+			name: "insert index out of bounds",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env", Op: config.PathOpInsert, Index: index(4), Value: map[string]any{"name": "X"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "append to non-array",
+			paths: []config.PathValue{
+				{Path: "$.spec.schedule", Op: config.PathOpAppend, Value: "x"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newOperationsBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPaths(&cw, tt.paths)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			assert.Equal(t, newOperationsBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestPathEvaluator_insertAtPath_NestedArray(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	// Steps are a list of parallel step groups, so an index at the end of the path selects a group
+	target := map[string]any{
+		"steps": []any{
+			[]any{map[string]any{"name": "a"}},
+		},
+	}
+
+	require.NoError(t, evaluator.insertAtPath(target, "$.steps[0]", config.PathOpAppend, nil, map[string]any{"name": "b"}))
+	assert.Equal(t, []any{
+		[]any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+	}, target["steps"])
+}
+
+func TestPathEvaluator_ApplyPaths_Merge(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name      string
+		paths     []config.PathValue
+		expectErr bool
+		validator func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "merge keeps existing keys",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Op: config.PathOpMerge, Value: map[string]any{"team": "data", "tier": "batch"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app": "base", "team": "data", "tier": "batch"}, cw.Labels)
+			},
+		},
+		{
+			name: "null deletes keys",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Op: config.PathOpMerge, Value: map[string]any{"team": nil, "tier": "batch"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"app": "base", "tier": "batch"}, cw.Labels)
+			},
+		},
+		{
+			name: "nested maps are merged recursively and lists replaced",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[?(@.name == 'main')]", Op: config.PathOpMerge, Value: map[string]any{
+					"container": map[string]any{
+						"image": "alpine",
+						"args":  []any{"--verbose"},
+						"env":   []any{map[string]any{"name": "ONLY"}},
+					},
+				}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				container := cw.Spec.WorkflowSpec.Templates[0].Container
+				assert.Equal(t, "main", cw.Spec.WorkflowSpec.Templates[0].Name)
+				assert.Equal(t, "alpine", container.Image)
+				assert.Equal(t, []string{"--verbose"}, container.Args)
+				require.Len(t, container.Env, 1)
+				assert.Equal(t, "ONLY", container.Env[0].Name)
+			},
+		},
+		{
+			name: "merge creates missing map",
+			paths: []config.PathValue{
+				{Path: "$.metadata.annotations", Op: config.PathOpMerge, Value: `{"owner": "platform", "unused": null}`},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"owner": "platform"}, cw.Annotations)
+			},
+		},
+		{
+			name: "merge into document root",
+			paths: []config.PathValue{
+				{Path: "$", Op: config.PathOpMerge, Value: map[string]any{
+					"metadata": map[string]any{"namespace": "jobs", "labels": map[string]any{"app": nil}},
+				}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "base", cw.Name)
+				assert.Equal(t, "jobs", cw.Namespace)
+				assert.Equal(t, map[string]string{"team": "platform"}, cw.Labels)
+			},
+		},
+		{
+			name: "non-map value into document root",
+			paths: []config.PathValue{
+				{Path: "$", Op: config.PathOpMerge, Value: []any{"x"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newOperationsBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPaths(&cw, tt.paths)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			assert.Equal(t, newOperationsBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestPathEvaluator_ApplyPaths_Replace(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name          string
+		paths         []config.PathValue
+		errorContains string
+		validator     func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "rewrite registry keeping the rest of the image",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.image", Value: "registry.old/foo:1.2"},
+				{Path: "$.spec.workflowSpec.templates[0].container.image", Op: config.PathOpReplace, Pattern: `^registry\.old/`, Replacement: "registry.new/"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "registry.new/foo:1.2", cw.Spec.WorkflowSpec.Templates[0].Container.Image)
+			},
+		},
+		{
+			name: "capture groups",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.image", Value: "registry.old/foo:1.2"},
+				{Path: "$.spec.workflowSpec.templates[0].container.image", Op: config.PathOpReplace, Pattern: `^[^/]+/(?P<name>[^:]+):(\d+)\.(\d+)$`, Replacement: "mirror/${name}:$2.$3-patched"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "mirror/foo:1.2-patched", cw.Spec.WorkflowSpec.Templates[0].Container.Image)
+			},
+		},
+		{
+			name: "every occurrence in every match is replaced",
+			paths: []config.PathValue{
+				{Path: "$.spec.workflowSpec.templates[0].container.env[*].value", Op: config.PathOpReplace, Pattern: `\d`, Replacement: "n$0"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				env := cw.Spec.WorkflowSpec.Templates[0].Container.Env
+				assert.Equal(t, []string{"n1", "n2", "n3"}, []string{env[0].Value, env[1].Value, env[2].Value})
+			},
+		},
+		{
+			name: "suffix removal with empty replacement",
+			paths: []config.PathValue{
+				{Path: "$.metadata.name", Value: "report-staging"},
+				{Path: "$.metadata.name", Op: config.PathOpReplace, Pattern: `-staging$`},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "report", cw.Name)
+			},
+		},
+		{
+			name: "no match leaves the string unchanged",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.app", Op: config.PathOpReplace, Pattern: `^web$`, Replacement: "api"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "base", cw.Labels["app"])
+			},
+		},
+		{
+			name: "missing target",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels.missing", Op: config.PathOpReplace, Pattern: `a`, Replacement: "b"},
+			},
+			errorContains: "path not found: $.metadata.labels.missing matched nothing",
+		},
+		{
+			name: "optional missing target",
+			paths: []config.PathValue{
+				{Path: "$..sidecars[*].image", Op: config.PathOpReplace, Pattern: `a`, Replacement: "b", Optional: true},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, *newOperationsBaseCronWorkflow(), *cw)
+			},
+		},
+		{
+			name: "non-string target",
+			paths: []config.PathValue{
+				{Path: "$.metadata.labels", Op: config.PathOpReplace, Pattern: `a`, Replacement: "b"},
+			},
+			errorContains: "target of op replace is not a string, got object",
+		},
+		{
+			name: "invalid pattern",
+			paths: []config.PathValue{
+				{Path: "$.metadata.name", Op: config.PathOpReplace, Pattern: `(`},
+			},
+			errorContains: "invalid pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newOperationsBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPaths(&cw, tt.paths)
+
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			assert.Equal(t, newOperationsBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7386 Appendix A
+	tests := []struct {
+		target   any
+		patch    any
+		expected any
+	}{
+		{map[string]any{"a": "b"}, map[string]any{"a": "c"}, map[string]any{"a": "c"}},
+		{map[string]any{"a": "b"}, map[string]any{"b": "c"}, map[string]any{"a": "b", "b": "c"}},
+		{map[string]any{"a": "b"}, map[string]any{"a": nil}, map[string]any{}},
+		{map[string]any{"a": "b", "b": "c"}, map[string]any{"a": nil}, map[string]any{"b": "c"}},
+		{map[string]any{"a": []any{"b"}}, map[string]any{"a": "c"}, map[string]any{"a": "c"}},
+		{map[string]any{"a": "c"}, map[string]any{"a": []any{"b"}}, map[string]any{"a": []any{"b"}}},
+		{map[string]any{"a": map[string]any{"b": "c"}}, map[string]any{"a": map[string]any{"b": "d", "c": nil}}, map[string]any{"a": map[string]any{"b": "d"}}},
+		{map[string]any{"a": []any{map[string]any{"b": "c"}}}, map[string]any{"a": []any{1}}, map[string]any{"a": []any{1}}},
+		{[]any{"a", "b"}, []any{"c", "d"}, []any{"c", "d"}},
+		{map[string]any{"a": "b"}, []any{"c"}, []any{"c"}},
+		{map[string]any{"a": "foo"}, nil, nil},
+		{map[string]any{"a": "foo"}, "bar", "bar"},
+		{map[string]any{"e": nil}, map[string]any{"a": 1}, map[string]any{"e": nil, "a": 1}},
+		{[]any{1, 2}, map[string]any{"a": "b", "c": nil}, map[string]any{"a": "b"}},
+		{map[string]any{}, map[string]any{"a": map[string]any{"bb": map[string]any{"ccc": nil}}}, map[string]any{"a": map[string]any{"bb": map[string]any{}}}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, mergePatch(tt.target, tt.patch))
+	}
+}
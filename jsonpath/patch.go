@@ -0,0 +1,261 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+// ApplyPatches applies RFC 6902 JSON Patch operations to the target CronWorkflow in order.
+// Like ApplyPaths, the CronWorkflow is converted to a map, patched and converted back.
+func (pe *PathEvaluator) ApplyPatches(target *argoworkflowsv1alpha1.CronWorkflow, patches []config.JSONPatchOperation) error {
+	if len(patches) == 0 {
+		return nil // Nothing to apply
+	}
+
+	targetMap, err := pe.structToMap(target)
+	if err != nil {
+		return fmt.Errorf("failed to convert CronWorkflow to map: %w", err)
+	}
+
+	for i, patch := range patches {
+		if err := applyPatch(targetMap, patch); err != nil {
+			return fmt.Errorf("failed to apply patch %d (%s %s): %w", i, patch.Op, patch.Path, err)
+		}
+		pe.logger.Debug("Applied patch", "op", patch.Op, "path", patch.Path)
+	}
+
+	*target = argoworkflowsv1alpha1.CronWorkflow{}
+	if err := pe.mapToStruct(targetMap, target); err != nil {
+		return fmt.Errorf("failed to convert map back to CronWorkflow: %w", err)
+	}
+
+	return nil
+}
+
+// applyPatch applies a single JSON Patch operation to doc
+func applyPatch(doc map[string]any, patch config.JSONPatchOperation) error {
+	tokens, err := parseJSONPointer(patch.Path)
+	if err != nil {
+		return err
+	}
+
+	switch patch.Op {
+	case "test":
+		actual, err := getAtPointer(doc, tokens)
+		if err != nil {
+			return err
+		}
+		expected, err := normalizeJSON(patch.Value)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			return fmt.Errorf("test failed: value at %s is %v, expected %v", patch.Path, actual, expected)
+		}
+		return nil
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("patching the document root is not supported")
+	}
+
+	switch patch.Op {
+	case "add":
+		value, err := normalizeJSON(patch.Value)
+		if err != nil {
+			return err
+		}
+		return addAtPointer(doc, tokens, value)
+	case "remove":
+		_, err := removeAtPointer(doc, tokens)
+		return err
+	case "replace":
+		value, err := normalizeJSON(patch.Value)
+		if err != nil {
+			return err
+		}
+		if _, err := removeAtPointer(doc, tokens); err != nil {
+			return err
+		}
+		return addAtPointer(doc, tokens, value)
+	case "move", "copy":
+		fromTokens, err := parseJSONPointer(patch.From)
+		if err != nil {
+			return err
+		}
+		if len(fromTokens) == 0 {
+			return fmt.Errorf("from cannot be the document root")
+		}
+		var value any
+		if patch.Op == "move" {
+			if strings.HasPrefix(patch.Path, patch.From+"/") {
+				return fmt.Errorf("cannot move %s into its own child %s", patch.From, patch.Path)
+			}
+			value, err = removeAtPointer(doc, fromTokens)
+		} else {
+			value, err = getAtPointer(doc, fromTokens)
+			if err == nil {
+				// Copies must not share maps or slices with their source
+				value, err = normalizeJSON(value)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return addAtPointer(doc, tokens, value)
+	default:
+		return fmt.Errorf("unknown op %q", patch.Op)
+	}
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// getAtPointer returns the value referenced by tokens
+func getAtPointer(doc any, tokens []string) (any, error) {
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			value, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("key %s does not exist", token)
+			}
+			current = value
+		case []any:
+			i, err := pointerIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("cannot reference %s in a scalar value", token)
+		}
+	}
+	return current, nil
+}
+
+// addAtPointer adds value at tokens, setting map keys and inserting array elements ("-" appends)
+func addAtPointer(doc map[string]any, tokens []string, value any) error {
+	_, err := updateParent(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = pointerIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			return insertElement(node, config.PathOpInsert, &i, value)
+		default:
+			return nil, fmt.Errorf("cannot add %s to a scalar value", token)
+		}
+	})
+	return err
+}
+
+// removeAtPointer removes and returns the value at tokens
+func removeAtPointer(doc map[string]any, tokens []string) (any, error) {
+	var removed any
+	_, err := updateParent(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("key %s does not exist", token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := pointerIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return removeIndex(node, i), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %s from a scalar value", token)
+		}
+	})
+	return removed, err
+}
+
+// updateParent walks to the container holding the last token and replaces it with the result of
+// update, re-assigning every container on the way so that resized arrays are stored back
+func updateParent(node any, tokens []string, update func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return update(node, tokens[0])
+	}
+
+	child, err := getAtPointer(node, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := updateParent(child, tokens[1:], update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parent := node.(type) {
+	case map[string]any:
+		parent[tokens[0]] = updated
+	case []any:
+		i, _ := pointerIndex(tokens[0], len(parent)-1)
+		parent[i] = updated
+	}
+	return node, nil
+}
+
+// pointerIndex parses an array index token, which must be within [0, maxIndex]
+func pointerIndex(token string, maxIndex int) (int, error) {
+	// RFC 6901 does not allow signs or leading zeros in array indices
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.ContainsAny(token, "+-") {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > maxIndex {
+		return 0, fmt.Errorf("index %d is out of bounds", i)
+	}
+	return i, nil
+}
+
+// normalizeJSON converts a value decoded from YAML into its JSON representation
+// (float64 numbers, map[string]any objects) so it compares equal to values of the document
+func normalizeJSON(value any) (any, error) {
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("value cannot be represented as JSON: %w", err)
+	}
+	var result any
+	if err := json.Unmarshal(jsonBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package jsonpath
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+
+	"github.com/drumato/cron-workflow-replicator/config"
+)
+
+func TestPathEvaluator_ApplyPatches(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	evaluator := NewPathEvaluator(logger)
+
+	tests := []struct {
+		name      string
+		patches   []config.JSONPatchOperation
+		expectErr string
+		validator func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow)
+	}{
+		{
+			name: "add map key and array element",
+			patches: []config.JSONPatchOperation{
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]any{"owner": "platform"}},
+				{Op: "add", Path: "/spec/workflowSpec/templates/0/container/env/1", Value: map[string]any{"name": "INSERTED"}},
+				{Op: "add", Path: "/spec/workflowSpec/templates/0/container/env/-", Value: map[string]any{"name": "LAST"}},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, map[string]string{"owner": "platform"}, cw.Annotations)
+				env := cw.Spec.WorkflowSpec.Templates[0].Container.Env
+				require.Len(t, env, 5)
+				assert.Equal(t, "INSERTED", env[1].Name)
+				assert.Equal(t, "LAST", env[4].Name)
+			},
+		},
+		{
+			name: "remove and replace",
+			patches: []config.JSONPatchOperation{
+				{Op: "remove", Path: "/spec/workflowSpec/templates/1"},
+				{Op: "replace", Path: "/spec/schedule", Value: "*/5 * * * *"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Len(t, cw.Spec.WorkflowSpec.Templates, 1)
+				assert.Equal(t, "*/5 * * * *", cw.Spec.Schedule)
+			},
+		},
+		{
+			name: "move and copy",
+			patches: []config.JSONPatchOperation{
+				{Op: "copy", From: "/metadata/labels/app", Path: "/metadata/labels/copied"},
+				{Op: "move", From: "/spec/workflowSpec/templates/0/container/env/0", Path: "/spec/workflowSpec/templates/0/container/env/-"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "base", cw.Labels["copied"])
+				env := cw.Spec.WorkflowSpec.Templates[0].Container.Env
+				require.Len(t, env, 3)
+				assert.Equal(t, "SECOND", env[0].Name)
+				assert.Equal(t, "FIRST", env[2].Name)
+			},
+		},
+		{
+			name: "escaped pointer tokens",
+			patches: []config.JSONPatchOperation{
+				{Op: "add", Path: "/metadata/labels/app.kubernetes.io~1name", Value: "backup"},
+				{Op: "add", Path: "/metadata/labels/tilde~0key", Value: "yes"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "backup", cw.Labels["app.kubernetes.io/name"])
+				assert.Equal(t, "yes", cw.Labels["tilde~key"])
+			},
+		},
+		{
+			name: "successful test guards later operations",
+			patches: []config.JSONPatchOperation{
+				{Op: "test", Path: "/spec/workflowSpec/templates/0/name", Value: "main"},
+				{Op: "test", Path: "/spec/workflowSpec/templates/0/container/env/0", Value: map[string]any{"name": "FIRST", "value": "1"}},
+				{Op: "replace", Path: "/spec/workflowSpec/templates/0/container/image", Value: "alpine"},
+			},
+			validator: func(t *testing.T, cw *argoworkflowsv1alpha1.CronWorkflow) {
+				assert.Equal(t, "alpine", cw.Spec.WorkflowSpec.Templates[0].Container.Image)
+			},
+		},
+		{
+			name: "failed test",
+			patches: []config.JSONPatchOperation{
+				{Op: "test", Path: "/spec/workflowSpec/templates/0/name", Value: "other"},
+			},
+			expectErr: "test failed",
+		},
+		{
+			name: "remove missing key",
+			patches: []config.JSONPatchOperation{
+				{Op: "remove", Path: "/metadata/labels/missing"},
+			},
+			expectErr: "key missing does not exist",
+		},
+		{
+			name: "replace out of bounds",
+			patches: []config.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/workflowSpec/templates/2", Value: map[string]any{"name": "x"}},
+			},
+			expectErr: "index 2 is out of bounds",
+		},
+		{
+			name: "leading zero index",
+			patches: []config.JSONPatchOperation{
+				{Op: "remove", Path: "/spec/workflowSpec/templates/01"},
+			},
+			expectErr: `invalid array index "01"`,
+		},
+		{
+			name: "move into own child",
+			patches: []config.JSONPatchOperation{
+				{Op: "move", From: "/metadata", Path: "/metadata/nested"},
+			},
+			expectErr: "cannot move /metadata into its own child",
+		},
+		{
+			name: "document root",
+			patches: []config.JSONPatchOperation{
+				{Op: "replace", Path: "", Value: map[string]any{}},
+			},
+			expectErr: "patching the document root is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newOperationsBaseCronWorkflow()
+			cw := *base
+			err := evaluator.ApplyPatches(&cw, tt.patches)
+
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.validator(t, &cw)
+			assert.Equal(t, newOperationsBaseCronWorkflow(), base)
+		})
+	}
+}
+
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		pointer   string
+		expected  []string
+		expectErr bool
+	}{
+		{pointer: "", expected: []string{}},
+		{pointer: "/", expected: []string{""}},
+		{pointer: "/a/b", expected: []string{"a", "b"}},
+		{pointer: "/a~1b/c~0d", expected: []string{"a/b", "c~d"}},
+		{pointer: "/~01", expected: []string{"~1"}},
+		{pointer: "a/b", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			tokens, err := parseJSONPointer(tt.pointer)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tokens)
+		})
+	}
+}
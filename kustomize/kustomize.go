@@ -47,15 +47,15 @@ func (m *Manager) UpdateKustomization(outputDir string, generatedFiles []string,
 			slog.Warn("ignoring empty filename in generated files list", "outputDir", outputDir)
 			continue
 		}
-		if !strings.HasSuffix(file, ".yaml") && !strings.HasSuffix(file, ".yml") {
-			slog.Warn("ignoring non-YAML file in generated files list", "file", file, "outputDir", outputDir)
+		if !strings.HasSuffix(file, ".yaml") && !strings.HasSuffix(file, ".yml") && !strings.HasSuffix(file, ".json") {
+			slog.Warn("ignoring non-YAML/JSON file in generated files list", "file", file, "outputDir", outputDir)
 			continue
 		}
 		validFiles = append(validFiles, file)
 	}
 
 	if len(validFiles) == 0 {
-		slog.Debug("no valid YAML files to add to kustomization", "outputDir", outputDir, "originalCount", len(generatedFiles))
+		slog.Debug("no valid YAML/JSON files to add to kustomization", "outputDir", outputDir, "originalCount", len(generatedFiles))
 		return nil
 	}
 
@@ -227,7 +227,7 @@ func TestManager_UpdateKustomization_ErrorScenarios(t *testing.T) {
 				return outputDir
 			},
 			outputDir:      "/test/output",
-			generatedFiles: []string{"valid.yaml", "invalid.txt", "", "another.yml"},
+			generatedFiles: []string{"valid.yaml", "invalid.txt", "", "another.yml", "third.json"},
 			expectedError:  "", // Should filter and process only valid files
 		},
 		{
@@ -239,7 +239,7 @@ func TestManager_UpdateKustomization_ErrorScenarios(t *testing.T) {
 				return outputDir
 			},
 			outputDir:      "/test/output",
-			generatedFiles: []string{"invalid.txt", "another.json", ""},
+			generatedFiles: []string{"invalid.txt", "another.md", ""},
 			expectedError:  "", // Should return early with no valid files
 		},
 		{
@@ -303,7 +303,7 @@ func TestManager_UpdateKustomization_ErrorScenarios(t *testing.T) {
 				// Verify kustomization.yaml was created/updated if valid files were provided
 				validFilesCount := 0
 				for _, file := range tt.generatedFiles {
-					if file != "" && (strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml")) {
+					if file != "" && (strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml") || strings.HasSuffix(file, ".json")) {
 						validFilesCount++
 					}
 				}
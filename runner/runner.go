@@ -3,15 +3,20 @@ package runner
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 
+	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/drumato/cron-workflow-replicator/config"
 	"github.com/drumato/cron-workflow-replicator/filesystem"
 	"github.com/drumato/cron-workflow-replicator/jsonpath"
 	"github.com/drumato/cron-workflow-replicator/kustomize"
+	"github.com/drumato/cron-workflow-replicator/template"
 	"github.com/drumato/cron-workflow-replicator/types"
+	"gopkg.in/yaml.v3"
 )
 
 const autoGeneratedHeader = "# this file is auto generated; DO NOT EDIT\n"
@@ -22,6 +27,8 @@ type Runner struct {
 	fileReader       config.FileReader
 	kustomizeManager *kustomize.Manager
 	pathEvaluator    *jsonpath.PathEvaluator
+	variables        map[string]any
+	strictTemplates  *bool // nil to follow the API version of each unit
 }
 
 type RunnerOption func(*Runner)
@@ -65,6 +72,22 @@ func WithPathEvaluator(pe *jsonpath.PathEvaluator) RunnerOption {
 	}
 }
 
+// WithVariables sets the global variables (from --values) used to render value templates and
+// evaluate when conditions. Unit and value vars override them.
+func WithVariables(vars map[string]any) RunnerOption {
+	return func(r *Runner) {
+		r.variables = vars
+	}
+}
+
+// WithStrictTemplates sets whether value templates fail on missing variables, overriding the
+// default of each unit's API version
+func WithStrictTemplates(strict bool) RunnerOption {
+	return func(r *Runner) {
+		r.strictTemplates = &strict
+	}
+}
+
 func (r *Runner) Run(ctx context.Context, cfg config.Config, configDir string) error {
 	r.logger.Info("Runner started")
 
@@ -79,15 +102,58 @@ func (r *Runner) Run(ctx context.Context, cfg config.Config, configDir string) e
 	return nil
 }
 
+// Render runs the same pipeline as Run, but streams every generated CronWorkflow to w as a
+// multi-document YAML stream instead of writing files. Output directories and kustomization
+// files are never touched.
+func (r *Runner) Render(ctx context.Context, cfg config.Config, configDir string, w io.Writer) error {
+	r.logger.DebugContext(ctx, "Rendering configuration", slog.Any("config", cfg))
+
+	documents := 0
+	for i, unit := range cfg.Units {
+		unit, err := r.ExpandUnit(unit)
+		if err != nil {
+			return fmt.Errorf("failed to process unit %d: %w", i, err)
+		}
+		base, err := r.loadUnitBase(unit, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to process unit %d: %w", i, err)
+		}
+
+		for _, value := range unit.Values {
+			r.logger.DebugContext(ctx, "Rendering value", slog.String("filename", value.Filename))
+
+			out, err := r.renderValue(unit, base, value)
+			if err != nil {
+				return fmt.Errorf("failed to process unit %d: %w", i, err)
+			}
+
+			if documents > 0 {
+				out = append([]byte("---\n"), out...)
+			}
+			if _, err := w.Write(out); err != nil {
+				return fmt.Errorf("failed to write rendered manifest for %s: %w", value.Filename, err)
+			}
+			documents++
+		}
+	}
+
+	r.logger.DebugContext(ctx, "Rendering completed", slog.Int("documents", documents))
+	return nil
+}
+
 func (r *Runner) processUnit(ctx context.Context, unit config.Unit, configDir string) error {
+	unit, err := r.ExpandUnit(unit)
+	if err != nil {
+		return err
+	}
+
 	// Calculate absolute output directory from configDir + unit.OutputDirectory
 	absoluteOutputDir := filepath.Join(configDir, unit.OutputDirectory)
 
 	// Load base CronWorkflow from manifest if provided
-	baseCronWorkflow, err := unit.LoadBaseCronWorkflow(r.fileReader, configDir)
+	base, err := r.loadUnitBase(unit, configDir)
 	if err != nil {
-		r.logger.Error("Failed to load base CronWorkflow", "error", err)
-		return fmt.Errorf("failed to load base CronWorkflow: %w", err)
+		return err
 	}
 
 	if err := r.fsConnector.MkdirAll(absoluteOutputDir, 0o755); err != nil {
@@ -97,73 +163,323 @@ func (r *Runner) processUnit(ctx context.Context, unit config.Unit, configDir st
 
 	// Track generated files for kustomize
 	var generatedFiles []string
-	sameFilenameCounter := map[string]int{}
 
-	for _, value := range unit.Values {
-		r.logger.DebugContext(ctx, "Processing value", slog.String("filename", value.Filename))
+	switch unit.GetOutputMode() {
+	case config.OutputModeMultiDocument:
+		generatedFiles, err = r.writeMultiDocument(ctx, unit, base, absoluteOutputDir)
+	default:
+		generatedFiles, err = r.writePerValue(ctx, unit, base, absoluteOutputDir)
+	}
+	if err != nil {
+		return err
+	}
 
-		var filename string
-		if counter, exists := sameFilenameCounter[value.Filename]; exists {
-			filename = fmt.Sprintf("%s-%d.yaml", value.Filename, counter+1)
-		} else {
-			filename = fmt.Sprintf("%s.yaml", value.Filename)
+	// Update kustomization.yaml if kustomize is configured
+	if unit.Kustomize != nil && unit.Kustomize.UpdateResources {
+		r.logger.DebugContext(ctx, "Updating kustomization.yaml",
+			slog.String("outputDir", absoluteOutputDir),
+			slog.Any("generatedFiles", generatedFiles),
+			slog.Bool("recreateFile", unit.Kustomize.GetRecreateFile()))
+
+		if err := r.kustomizeManager.UpdateKustomization(absoluteOutputDir, generatedFiles, unit.Kustomize.GetRecreateFile()); err != nil {
+			r.logger.WarnContext(ctx, "Failed to update kustomization.yaml",
+				slog.String("error", err.Error()))
+			// Don't fail the entire process if kustomize update fails
 		}
-		outputYAMLPath := filepath.Join(absoluteOutputDir, filename)
-		generatedFiles = append(generatedFiles, filename)
-		r.logger.DebugContext(ctx, "Generating output file", slog.String("outputYAMLPath", outputYAMLPath))
+	}
+
+	return nil
+}
+
+// ExpandUnit returns a copy of the unit whose value filenames and path values are rendered as
+// templates against the variables of each value. Only values that declare vars, directly or through
+// their unit, are rendered, so that Argo's own {{...}} expressions in other values are left alone.
+// Missing variables are errors if templates are strict for the unit (see WithStrictTemplates).
+func (r *Runner) ExpandUnit(unit config.Unit) (config.Unit, error) {
+	renderer := r.templateRenderer(unit)
 
-		f, err := r.fsConnector.OpenFile(outputYAMLPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	values := make([]config.Value, len(unit.Values))
+	for i, value := range unit.Values {
+		expanded, err := r.expandValue(renderer, unit, value)
 		if err != nil {
-			r.logger.Error("Failed to open output file", "file", outputYAMLPath, "error", err)
-			return fmt.Errorf("failed to open output file %s: %w", outputYAMLPath, err)
+			return config.Unit{}, fmt.Errorf("failed to render templates of value %d: %w", i, err)
 		}
+		values[i] = expanded
+	}
+	unit.Values = values
+	return unit, nil
+}
 
-		// Start with the base CronWorkflow (deep copy to avoid modifying the original)
-		cw := *baseCronWorkflow
+// expandValue renders the filename and path values of a value as templates
+func (r *Runner) expandValue(renderer *template.TemplateRenderer, unit config.Unit, value config.Value) (config.Value, error) {
+	if unit.Vars == nil && value.Vars == nil {
+		return value, nil
+	}
+	vars := r.valueVariables(unit, value)
+
+	filename, err := renderer.RenderString("filename", value.Filename, vars)
+	if err != nil {
+		return config.Value{}, err
+	}
+	if filename == "" {
+		return config.Value{}, fmt.Errorf("filename %q rendered to an empty string", value.Filename)
+	}
+	value.Filename = filename
 
-		// Apply paths from the value using JSONPath evaluation
-		if err := r.pathEvaluator.ApplyPaths(&cw, value.Paths); err != nil {
-			r.logger.Error("Failed to apply paths", "filename", value.Filename, "error", err)
-			return fmt.Errorf("failed to apply paths for %s: %w", value.Filename, err)
+	paths := make([]config.PathValue, len(value.Paths))
+	for i, pv := range value.Paths {
+		rendered, err := renderer.RenderValue(pv.Path, pv.Value, vars)
+		if err != nil {
+			return config.Value{}, err
 		}
+		pv.Value = rendered
+		paths[i] = pv
+	}
+	value.Paths = paths
+	return value, nil
+}
+
+// templateRenderer returns the renderer for the value templates and base manifest of the unit
+func (r *Runner) templateRenderer(unit config.Unit, opts ...template.Option) *template.TemplateRenderer {
+	strict := unit.APIVersion.StrictTemplatesByDefault()
+	if r.strictTemplates != nil {
+		strict = *r.strictTemplates
+	}
+	return template.New(r.logger, append([]template.Option{template.WithStrict(strict)}, opts...)...)
+}
+
+// valueVariables returns the variables of a value: the global variables overridden by the unit's
+// vars, overridden by the value's vars
+func (r *Runner) valueVariables(unit config.Unit, value config.Value) map[string]any {
+	return template.MergeVariables(r.variables, unit.Vars, value.Vars)
+}
 
-		cleanCW := types.NewCleanCronWorkflow(&cw)
-		out, err := cleanCW.ToYAMLWithIndent(unit.GetIndent())
+// unitBase holds the base manifest of a unit in the forms needed to render its values
+type unitBase struct {
+	cronWorkflow *argoworkflowsv1alpha1.CronWorkflow
+	// node is the raw base manifest document; it is only loaded when the unit's output options need it
+	node *yaml.Node
+	// forValue loads the base of a value instead when the base manifest is a template; see templateBase
+	forValue func(value config.Value) (*unitBase, error)
+}
+
+// loadUnitBase loads the base manifest of the unit. If the unit's base manifest is a template, it is
+// rendered with the variables of each value when that value is rendered.
+func (r *Runner) loadUnitBase(unit config.Unit, configDir string) (*unitBase, error) {
+	if unit.TemplateBase {
+		// A "<no value>" would end up in the generated manifest, e.g. as its namespace, so it is an
+		// error even when the unit is not strict
+		renderer := r.templateRenderer(unit, template.WithNoValueCheck(true))
+		return &unitBase{forValue: func(value config.Value) (*unitBase, error) {
+			fileReader := &templateFileReader{
+				fileReader: r.fileReader,
+				renderer:   renderer,
+				vars:       r.valueVariables(unit, value),
+			}
+			return r.readUnitBase(unit, configDir, fileReader)
+		}}, nil
+	}
+
+	return r.readUnitBase(unit, configDir, r.fileReader)
+}
+
+// readUnitBase reads the base manifest of the unit through fileReader
+func (r *Runner) readUnitBase(unit config.Unit, configDir string, fileReader config.FileReader) (*unitBase, error) {
+	baseCronWorkflow, err := unit.LoadBaseCronWorkflow(fileReader, configDir)
+	if err != nil {
+		r.logger.Error("Failed to load base CronWorkflow", "error", err)
+		return nil, fmt.Errorf("failed to load base CronWorkflow: %w", err)
+	}
+
+	base := &unitBase{cronWorkflow: baseCronWorkflow}
+	if unit.GetKeyOrder() == config.KeyOrderBaseManifest || unit.PreserveComments {
+		base.node, err = unit.LoadBaseManifestNode(fileReader, configDir)
 		if err != nil {
-			r.logger.Error("Failed to marshal cronworkflow to YAML", "file", outputYAMLPath, "error", err)
-			return fmt.Errorf("failed to marshal cronworkflow to yaml for file %s: %w", outputYAMLPath, err)
+			r.logger.Error("Failed to load base manifest node", "error", err)
+			return nil, fmt.Errorf("failed to load base manifest node: %w", err)
 		}
+	}
+
+	return base, nil
+}
+
+// templateFileReader renders the files it reads as templates with the given variables
+type templateFileReader struct {
+	fileReader config.FileReader
+	renderer   *template.TemplateRenderer
+	vars       map[string]any
+}
+
+func (fr *templateFileReader) ReadFile(filename string) ([]byte, error) {
+	data, err := fr.fileReader.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return fr.renderer.RenderManifest(filename, data, fr.vars)
+}
+
+// yamlOptions builds the YAML encoding options of the unit
+func yamlOptions(unit config.Unit, base *unitBase) types.YAMLOptions {
+	opts := types.YAMLOptions{Indent: unit.GetIndent()}
+	switch unit.GetKeyOrder() {
+	case config.KeyOrderKubernetes:
+		opts.KeyOrder = types.KeyOrderKubernetes
+	case config.KeyOrderBaseManifest:
+		opts.KeyOrder = types.KeyOrderReference
+		opts.Reference = base.node
+	}
+	if unit.PreserveComments {
+		opts.PreserveComments = true
+		opts.Reference = base.node
+	}
+	return opts
+}
 
-		out = append([]byte(autoGeneratedHeader), out...)
+// writePerValue writes one file per value into the output directory and returns the generated file names
+func (r *Runner) writePerValue(ctx context.Context, unit config.Unit, base *unitBase, absoluteOutputDir string) ([]string, error) {
+	filenames := unit.ValueFilenames()
 
-		n, err := f.Write(out)
+	for i, value := range unit.Values {
+		r.logger.DebugContext(ctx, "Processing value", slog.String("filename", value.Filename))
+
+		outputPath := filepath.Join(absoluteOutputDir, filenames[i])
+		r.logger.DebugContext(ctx, "Generating output file", slog.String("outputPath", outputPath))
+
+		out, err := r.renderValue(unit, base, value)
 		if err != nil {
-			return fmt.Errorf("failed to write to output file %s: %w", outputYAMLPath, err)
+			return nil, err
 		}
-		if n < len(out) {
-			return fmt.Errorf("incomplete write to output file %s: wrote %d bytes, expected %d bytes", outputYAMLPath, n, len(out))
+
+		// JSON has no comment syntax, so only YAML files get the header
+		if unit.GetOutputFormat() == config.OutputFormatYAML {
+			out = append([]byte(autoGeneratedHeader), out...)
 		}
-		if err := f.Close(); err != nil {
-			return fmt.Errorf("failed to close output file %s: %w", outputYAMLPath, err)
+
+		if err := r.writeFile(outputPath, out); err != nil {
+			return nil, err
 		}
+	}
 
-		// Update the counter for duplicate filenames
-		sameFilenameCounter[value.Filename]++
+	return filenames, nil
+}
+
+// writeMultiDocument writes every value of the unit into a single multi-document YAML file.
+// Documents are ordered by their per-value file name so the output is stable regardless of
+// the order in which values are declared.
+func (r *Runner) writeMultiDocument(ctx context.Context, unit config.Unit, base *unitBase, absoluteOutputDir string) ([]string, error) {
+	filenames := unit.ValueFilenames()
+	order := make([]int, len(unit.Values))
+	for i := range order {
+		order[i] = i
 	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return filenames[order[a]] < filenames[order[b]]
+	})
 
-	// Update kustomization.yaml if kustomize is configured
-	if unit.Kustomize != nil && unit.Kustomize.UpdateResources {
-		r.logger.DebugContext(ctx, "Updating kustomization.yaml",
-			slog.String("outputDir", absoluteOutputDir),
-			slog.Any("generatedFiles", generatedFiles),
-			slog.Bool("recreateFile", unit.Kustomize.GetRecreateFile()))
+	outputFilename := unit.OutputFilenames()[0]
+	outputYAMLPath := filepath.Join(absoluteOutputDir, outputFilename)
+	r.logger.DebugContext(ctx, "Generating multi-document output file", slog.String("outputYAMLPath", outputYAMLPath))
 
-		if err := r.kustomizeManager.UpdateKustomization(absoluteOutputDir, generatedFiles, unit.Kustomize.GetRecreateFile()); err != nil {
-			r.logger.WarnContext(ctx, "Failed to update kustomization.yaml",
-				slog.String("error", err.Error()))
-			// Don't fail the entire process if kustomize update fails
+	out := []byte(autoGeneratedHeader)
+	for n, i := range order {
+		value := unit.Values[i]
+		r.logger.DebugContext(ctx, "Processing value", slog.String("filename", value.Filename))
+
+		doc, err := r.renderValue(unit, base, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			out = append(out, []byte("---\n")...)
+		}
+		out = append(out, doc...)
+	}
+
+	if err := r.writeFile(outputYAMLPath, out); err != nil {
+		return nil, err
+	}
+
+	return []string{outputFilename}, nil
+}
+
+// renderValue applies the paths of a value to a copy of the base CronWorkflow and marshals the result
+// in the unit's output format
+func (r *Runner) renderValue(unit config.Unit, base *unitBase, value config.Value) ([]byte, error) {
+	if base.forValue != nil {
+		valueBase, err := base.forValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load base manifest for %s: %w", value.Filename, err)
+		}
+		base = valueBase
+	}
+
+	// Start with the base CronWorkflow (deep copy to avoid modifying the original)
+	cw := *base.cronWorkflow
+
+	// Apply JSON Patch operations and paths from the value in the configured order
+	if value.GetPatchOrder() == config.PatchOrderBefore {
+		if err := r.applyPatches(&cw, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply paths from the value using JSONPath evaluation
+	if err := r.pathEvaluator.ApplyPathsWithVars(&cw, value.Paths, r.valueVariables(unit, value)); err != nil {
+		r.logger.Error("Failed to apply paths", "filename", value.Filename, "error", err)
+		return nil, fmt.Errorf("failed to apply paths for %s: %w", value.Filename, err)
+	}
+
+	if value.GetPatchOrder() == config.PatchOrderAfter {
+		if err := r.applyPatches(&cw, value); err != nil {
+			return nil, err
 		}
 	}
 
+	cleanCW := types.NewCleanCronWorkflow(&cw)
+	var out []byte
+	var err error
+	switch unit.GetOutputFormat() {
+	case config.OutputFormatJSON:
+		out, err = cleanCW.ToJSONWithIndent(unit.GetIndent())
+	default:
+		out, err = cleanCW.ToYAMLWithOptions(yamlOptions(unit, base))
+	}
+	if err != nil {
+		r.logger.Error("Failed to marshal cronworkflow", "filename", value.Filename, "format", unit.GetOutputFormat(), "error", err)
+		return nil, fmt.Errorf("failed to marshal cronworkflow to %s for %s: %w", unit.GetOutputFormat(), value.Filename, err)
+	}
+
+	return out, nil
+}
+
+// applyPatches applies the JSON Patch operations of a value to the CronWorkflow
+func (r *Runner) applyPatches(cw *argoworkflowsv1alpha1.CronWorkflow, value config.Value) error {
+	if err := r.pathEvaluator.ApplyPatches(cw, value.Patches); err != nil {
+		r.logger.Error("Failed to apply patches", "filename", value.Filename, "error", err)
+		return fmt.Errorf("failed to apply patches for %s: %w", value.Filename, err)
+	}
+	return nil
+}
+
+// writeFile writes the given content to path through the runner's filesystem
+func (r *Runner) writeFile(path string, out []byte) error {
+	f, err := r.fsConnector.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		r.logger.Error("Failed to open output file", "file", path, "error", err)
+		return fmt.Errorf("failed to open output file %s: %w", path, err)
+	}
+
+	n, err := f.Write(out)
+	if err != nil {
+		return fmt.Errorf("failed to write to output file %s: %w", path, err)
+	}
+	if n < len(out) {
+		return fmt.Errorf("incomplete write to output file %s: wrote %d bytes, expected %d bytes", path, n, len(out))
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close output file %s: %w", path, err)
+	}
+
 	return nil
 }
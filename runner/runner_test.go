@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -699,6 +700,350 @@ func (r *ErrorFileReader) ReadFile(filename string) ([]byte, error) {
 	return nil, os.ErrNotExist
 }
 
+// newTestRunner returns a runner that reads and writes the returned in-memory file system
+func newTestRunner(t *testing.T, opts ...RunnerOption) (*Runner, *filesystem.InMemoryFileSystem) {
+	t.Helper()
+	fs := filesystem.NewInMemoryFileSystem()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	runner := New(logger, append([]RunnerOption{
+		WithFileSystem(fs),
+		WithFileReader(&FilesystemFileReader{fs: fs}),
+		WithKustomizeManager(kustomize.NewManager(fs)),
+	}, opts...)...)
+	return runner, fs
+}
+
+// strPtr returns a pointer to s, for optional config fields
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestRunner_MultiDocumentOutputMode(t *testing.T) {
+	runner, fs := newTestRunner(t)
+
+	unit := config.Unit{
+		OutputDirectory: "output",
+		APIVersion:      config.APIVersionV1Alpha1,
+		OutputMode:      config.OutputModeMultiDocument,
+		OutputFilename:  strPtr("production"),
+		Kustomize: &config.KustomizeConfig{
+			UpdateResources: true,
+		},
+		Values: []config.Value{
+			{
+				Filename: "weekly",
+				Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "weekly-job"}},
+			},
+			{
+				Filename: "daily",
+				Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "daily-job"}},
+			},
+		},
+	}
+
+	err := runner.processUnit(context.Background(), unit, "/config")
+	require.NoError(t, err)
+
+	// Per-value files must not be written
+	assert.False(t, fs.Exists("/config/output/weekly.yaml"))
+	assert.False(t, fs.Exists("/config/output/daily.yaml"))
+
+	content, err := fs.ReadFile("/config/output/production.yaml")
+	require.NoError(t, err)
+
+	// Header appears exactly once, at the top
+	assert.True(t, strings.HasPrefix(string(content), "# this file is auto generated; DO NOT EDIT\n"))
+	assert.Equal(t, 1, strings.Count(string(content), "# this file is auto generated; DO NOT EDIT"))
+
+	// Documents are ordered by filename, not by declaration order
+	docs := strings.Split(string(content), "---\n")
+	require.Len(t, docs, 2)
+	var first, second argoworkflowsv1alpha1.CronWorkflow
+	require.NoError(t, kyaml.Unmarshal([]byte(docs[0]), &first))
+	require.NoError(t, kyaml.Unmarshal([]byte(docs[1]), &second))
+	assert.Equal(t, "daily-job", first.Name)
+	assert.Equal(t, "weekly-job", second.Name)
+
+	// kustomization.yaml references only the single file
+	kustomizationData, err := fs.ReadFile("/config/output/kustomization.yaml")
+	require.NoError(t, err)
+	var kustomization types.Kustomization
+	require.NoError(t, kyaml.Unmarshal(kustomizationData, &kustomization))
+	assert.Equal(t, []string{"production.yaml"}, kustomization.Resources)
+}
+
+func TestRunner_JSONOutputFormat(t *testing.T) {
+	runner, fs := newTestRunner(t)
+
+	unit := config.Unit{
+		OutputDirectory: "output",
+		APIVersion:      config.APIVersionV1Alpha1,
+		OutputFormat:    config.OutputFormatJSON,
+		Kustomize: &config.KustomizeConfig{
+			UpdateResources: true,
+		},
+		Values: []config.Value{
+			{
+				Filename: "json-job",
+				Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "json-job"}},
+			},
+		},
+	}
+
+	err := runner.processUnit(context.Background(), unit, "/config")
+	require.NoError(t, err)
+
+	assert.False(t, fs.Exists("/config/output/json-job.yaml"))
+	content, err := fs.ReadFile("/config/output/json-job.json")
+	require.NoError(t, err)
+
+	// JSON output has no comment header and is valid JSON
+	assert.True(t, strings.HasPrefix(string(content), "{"))
+	var cw argoworkflowsv1alpha1.CronWorkflow
+	require.NoError(t, json.Unmarshal(content, &cw))
+	assert.Equal(t, "json-job", cw.Name)
+
+	kustomizationData, err := fs.ReadFile("/config/output/kustomization.yaml")
+	require.NoError(t, err)
+	var kustomization types.Kustomization
+	require.NoError(t, kyaml.Unmarshal(kustomizationData, &kustomization))
+	assert.Equal(t, []string{"json-job.json"}, kustomization.Resources)
+}
+
+func TestRunner_BaseManifestKeyOrder(t *testing.T) {
+	baseManifest := `kind: CronWorkflow
+apiVersion: argoproj.io/v1alpha1
+metadata:
+  name: base
+spec:
+  schedule: "0 0 * * *"
+  timezone: UTC
+`
+	runner, fs := newTestRunner(t)
+	require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+	unit := config.Unit{
+		BaseManifestPath: strPtr("base.yaml"),
+		OutputDirectory:  "output",
+		APIVersion:       config.APIVersionV1Alpha1,
+		KeyOrder:         config.KeyOrderBaseManifest,
+		Values: []config.Value{
+			{
+				Filename: "ordered",
+				Paths:    []config.PathValue{{Path: "$.spec.concurrencyPolicy", Value: "Forbid"}},
+			},
+		},
+	}
+
+	err := runner.processUnit(context.Background(), unit, "/config")
+	require.NoError(t, err)
+
+	content, err := fs.ReadFile("/config/output/ordered.yaml")
+	require.NoError(t, err)
+
+	expected := `# this file is auto generated; DO NOT EDIT
+kind: CronWorkflow
+apiVersion: argoproj.io/v1alpha1
+metadata:
+  name: base
+spec:
+  schedule: 0 0 * * *
+  timezone: UTC
+  concurrencyPolicy: Forbid
+`
+	assert.Equal(t, expected, string(content))
+}
+
+func TestRunner_PreserveComments(t *testing.T) {
+	baseManifest := `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base
+spec:
+  # retries are disabled on purpose
+  schedule: "0 0 * * *" # daily
+`
+	runner, fs := newTestRunner(t)
+	require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+	unit := config.Unit{
+		BaseManifestPath: strPtr("base.yaml"),
+		OutputDirectory:  "output",
+		APIVersion:       config.APIVersionV1Alpha1,
+		PreserveComments: true,
+		Values: []config.Value{
+			{
+				Filename: "first",
+				Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "first"}},
+			},
+			{
+				Filename: "second",
+				Paths:    []config.PathValue{{Path: "$.spec.schedule", Value: "0 1 * * *"}},
+			},
+		},
+	}
+
+	err := runner.processUnit(context.Background(), unit, "/config")
+	require.NoError(t, err)
+
+	first, err := fs.ReadFile("/config/output/first.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `# this file is auto generated; DO NOT EDIT
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: first
+spec:
+  # retries are disabled on purpose
+  schedule: "0 0 * * *" # daily
+`, string(first))
+
+	second, err := fs.ReadFile("/config/output/second.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `# this file is auto generated; DO NOT EDIT
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base
+spec:
+  # retries are disabled on purpose
+  schedule: "0 1 * * *" # daily
+`, string(second))
+}
+
+func TestRunner_Render(t *testing.T) {
+	runner, fs := newTestRunner(t)
+
+	cfg := config.Config{
+		Units: []config.Unit{
+			{
+				OutputDirectory: "output-a",
+				APIVersion:      config.APIVersionV1Alpha1,
+				Kustomize: &config.KustomizeConfig{
+					UpdateResources: true,
+				},
+				Values: []config.Value{
+					{
+						Filename: "first",
+						Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "first-job"}},
+					},
+					{
+						Filename: "second",
+						Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "second-job"}},
+					},
+				},
+			},
+			{
+				OutputDirectory: "output-b",
+				APIVersion:      config.APIVersionV1Alpha1,
+				Values: []config.Value{
+					{
+						Filename: "third",
+						Paths:    []config.PathValue{{Path: "$.metadata.name", Value: "third-job"}},
+					},
+				},
+			},
+		},
+	}
+
+	var buf strings.Builder
+	err := runner.Render(context.Background(), cfg, "/config", &buf)
+	require.NoError(t, err)
+
+	docs := strings.Split(buf.String(), "---\n")
+	require.Len(t, docs, 3)
+	for i, expectedName := range []string{"first-job", "second-job", "third-job"} {
+		var cw argoworkflowsv1alpha1.CronWorkflow
+		require.NoError(t, kyaml.Unmarshal([]byte(docs[i]), &cw))
+		assert.Equal(t, expectedName, cw.Name)
+	}
+	assert.NotContains(t, buf.String(), "# this file is auto generated")
+
+	// Nothing is written to the filesystem
+	assert.False(t, fs.Exists("/config/output-a/first.yaml"))
+	assert.False(t, fs.Exists("/config/output-a/kustomization.yaml"))
+	assert.False(t, fs.Exists("/config/output-b/third.yaml"))
+}
+
+func TestRunner_JSONPatches(t *testing.T) {
+	baseManifest := `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base
+  labels:
+    app: base
+spec:
+  schedule: "0 0 * * *"
+  workflowSpec:
+    entrypoint: main
+    templates:
+    - name: main
+      container:
+        image: busybox
+`
+	// The patch replaces the label set by the path when applied after it, and is overwritten by
+	// the path when applied before it
+	paths := []config.PathValue{{Path: "$.metadata.labels.app", Value: "from-path"}}
+	patches := []config.JSONPatchOperation{
+		{Op: "test", Path: "/spec/workflowSpec/entrypoint", Value: "main"},
+		{Op: "replace", Path: "/metadata/labels/app", Value: "from-patch"},
+		{Op: "add", Path: "/spec/workflowSpec/templates/-", Value: map[string]any{"name": "sidecar"}},
+	}
+
+	tests := []struct {
+		name        string
+		patchOrder  config.PatchOrder
+		expectedApp string
+	}{
+		{name: "default order applies patches after paths", expectedApp: "from-patch"},
+		{name: "after", patchOrder: config.PatchOrderAfter, expectedApp: "from-patch"},
+		{name: "before", patchOrder: config.PatchOrderBefore, expectedApp: "from-path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, fs := newTestRunner(t)
+			require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+			unit := config.Unit{
+				BaseManifestPath: strPtr("base.yaml"),
+				OutputDirectory:  "output",
+				APIVersion:       config.APIVersionV1Alpha1,
+				Values: []config.Value{
+					{Filename: "job", Paths: paths, Patches: patches, PatchOrder: tt.patchOrder},
+				},
+			}
+			require.NoError(t, runner.processUnit(context.Background(), unit, "/config"))
+
+			content, err := fs.ReadFile("/config/output/job.yaml")
+			require.NoError(t, err)
+			var cw argoworkflowsv1alpha1.CronWorkflow
+			require.NoError(t, kyaml.Unmarshal(content, &cw))
+			assert.Equal(t, tt.expectedApp, cw.Labels["app"])
+			require.Len(t, cw.Spec.WorkflowSpec.Templates, 2)
+			assert.Equal(t, "sidecar", cw.Spec.WorkflowSpec.Templates[1].Name)
+		})
+	}
+
+	t.Run("failed test operation", func(t *testing.T) {
+		runner, fs := newTestRunner(t)
+		require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+		unit := config.Unit{
+			BaseManifestPath: strPtr("base.yaml"),
+			OutputDirectory:  "output",
+			APIVersion:       config.APIVersionV1Alpha1,
+			Values: []config.Value{
+				{Filename: "job", Patches: []config.JSONPatchOperation{{Op: "test", Path: "/spec/schedule", Value: "@daily"}}},
+			},
+		}
+		err := runner.processUnit(context.Background(), unit, "/config")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to apply patches for job")
+		assert.Contains(t, err.Error(), "test failed")
+	})
+}
+
 func TestRunner_processUnit_ErrorScenarios(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1111,3 +1456,267 @@ spec:
 	assert.Len(t, cw.Spec.WorkflowSpec.Arguments.Parameters, 1, "Should have base parameters")
 	assert.Equal(t, "base-param", cw.Spec.WorkflowSpec.Arguments.Parameters[0].Name, "Base parameter name should be preserved")
 }
+
+func TestRunner_WhenConditions(t *testing.T) {
+	baseManifest := `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base
+spec:
+  schedule: "0 0 * * *"
+  workflowSpec:
+    entrypoint: main
+    templates:
+    - name: main
+      container:
+        image: busybox
+`
+	paths := []config.PathValue{
+		{Path: "$.spec.workflowSpec.templates[0].retryStrategy.limit", Value: 3, When: []config.Condition{{Path: "$.spec.workflowSpec.templates[0].container"}}},
+		{Path: "$.metadata.labels.env", Value: "prod", When: []config.Condition{{Var: "environment", Equals: "production"}}},
+	}
+
+	tests := []struct {
+		name        string
+		vars        map[string]any
+		expectedEnv string
+	}{
+		{name: "without variables", expectedEnv: ""},
+		{name: "condition on variables holds", vars: map[string]any{"environment": "production"}, expectedEnv: "prod"},
+		{name: "condition on variables does not hold", vars: map[string]any{"environment": "staging"}, expectedEnv: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, fs := newTestRunner(t, WithVariables(tt.vars))
+			require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+			unit := config.Unit{
+				BaseManifestPath: strPtr("base.yaml"),
+				OutputDirectory:  "output",
+				APIVersion:       config.APIVersionV1Alpha1,
+				Values:           []config.Value{{Filename: "job", Paths: paths}},
+			}
+			require.NoError(t, runner.processUnit(context.Background(), unit, "/config"))
+
+			content, err := fs.ReadFile("/config/output/job.yaml")
+			require.NoError(t, err)
+			var cw argoworkflowsv1alpha1.CronWorkflow
+			require.NoError(t, kyaml.Unmarshal(content, &cw))
+			require.NotNil(t, cw.Spec.WorkflowSpec.Templates[0].RetryStrategy)
+			assert.Equal(t, "3", cw.Spec.WorkflowSpec.Templates[0].RetryStrategy.Limit.String())
+			assert.Equal(t, tt.expectedEnv, cw.Labels["env"])
+		})
+	}
+}
+
+func TestRunner_ValueVars(t *testing.T) {
+	baseManifest := `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base
+spec:
+  schedule: "0 0 * * *"
+  workflowSpec:
+    entrypoint: main
+    templates:
+    - name: main
+      container:
+        image: busybox
+`
+	runner, fs := newTestRunner(t, WithVariables(map[string]any{"environment": "production", "team": "global", "parallelism": 4}))
+	require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+	unit := config.Unit{
+		BaseManifestPath: strPtr("base.yaml"),
+		OutputDirectory:  "output",
+		APIVersion:       config.APIVersionV1Alpha1,
+		Vars:             map[string]any{"team": "data"},
+		Values: []config.Value{
+			{
+				Filename: "{{ .team }}-{{ .job }}",
+				Vars:     map[string]any{"job": "backup"},
+				Paths: []config.PathValue{
+					{Path: "$.metadata.name", Value: "{{ .team }}-{{ .job }}-{{ .environment }}"},
+					{Path: "$.spec.workflowSpec.parallelism", Value: "{{ .parallelism }}"},
+					{Path: "$.metadata.labels", Value: map[string]any{"team": "{{ .team }}"}},
+					{Path: "$.metadata.labels.job", Value: "yes", When: []config.Condition{{Var: "job", Equals: "backup"}}},
+				},
+			},
+			{
+				Filename: "{{ .team }}-report",
+				Vars:     map[string]any{"team": "finance"},
+			},
+		},
+	}
+	require.NoError(t, runner.processUnit(context.Background(), unit, "/config"))
+
+	content, err := fs.ReadFile("/config/output/data-backup.yaml")
+	require.NoError(t, err)
+	var cw argoworkflowsv1alpha1.CronWorkflow
+	require.NoError(t, kyaml.Unmarshal(content, &cw))
+	assert.Equal(t, "data-backup-production", cw.Name)
+	require.NotNil(t, cw.Spec.WorkflowSpec.Parallelism)
+	assert.Equal(t, int64(4), *cw.Spec.WorkflowSpec.Parallelism)
+	assert.Equal(t, map[string]string{"team": "data", "job": "yes"}, cw.Labels)
+
+	_, err = fs.ReadFile("/config/output/finance-report.yaml")
+	assert.NoError(t, err)
+
+	t.Run("values without vars are not rendered", func(t *testing.T) {
+		unit := config.Unit{
+			Values: []config.Value{{
+				Filename: "job",
+				Paths:    []config.PathValue{{Path: "$.spec.workflowSpec.templates[0].container.args", Value: []any{"{{inputs.parameters.message}}"}}},
+			}},
+		}
+		expanded, err := runner.ExpandUnit(unit)
+		require.NoError(t, err)
+		assert.Equal(t, unit, expanded)
+	})
+
+	t.Run("template error names the value", func(t *testing.T) {
+		unit := config.Unit{
+			Vars: map[string]any{},
+			Values: []config.Value{
+				{Filename: "ok"},
+				{Filename: "job", Paths: []config.PathValue{{Path: "$.metadata.name", Value: "{{ .job"}}},
+			},
+		}
+		_, err := runner.ExpandUnit(unit)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to render templates of value 1: failed to render $.metadata.name")
+	})
+
+	t.Run("filename rendered to an empty string", func(t *testing.T) {
+		unit := config.Unit{
+			Values: []config.Value{{Filename: "{{ .empty }}", Vars: map[string]any{"empty": ""}}},
+		}
+		_, err := runner.ExpandUnit(unit)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `filename "{{ .empty }}" rendered to an empty string`)
+	})
+}
+
+func TestRunner_StrictTemplates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	unit := config.Unit{
+		APIVersion: config.APIVersionV1Alpha1,
+		Vars:       map[string]any{"team": "data"},
+		Values:     []config.Value{{Filename: "{{ .tem }}-backup"}},
+	}
+
+	t.Run("v1alpha1 is lenient by default", func(t *testing.T) {
+		expanded, err := New(logger).ExpandUnit(unit)
+		require.NoError(t, err)
+		assert.Equal(t, "<no value>-backup", expanded.Values[0].Filename)
+	})
+
+	t.Run("strict fails on missing variables", func(t *testing.T) {
+		_, err := New(logger, WithStrictTemplates(true)).ExpandUnit(unit)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to render templates of value 0: failed to render filename")
+		assert.Contains(t, err.Error(), `map has no entry for key "tem"`)
+	})
+
+	t.Run("unknown API versions are not strict by default", func(t *testing.T) {
+		unit := unit
+		unit.APIVersion = "v1alpah1"
+		expanded, err := New(logger).ExpandUnit(unit)
+		require.NoError(t, err)
+		assert.Equal(t, "<no value>-backup", expanded.Values[0].Filename)
+	})
+}
+
+func TestRunner_TemplateBase(t *testing.T) {
+	baseManifest := `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: {{ .Var.job }}
+  # namespace of the environment
+  namespace: {{ .Var.namespace }}
+spec:
+  schedule: "0 0 * * *"
+  workflowSpec:
+    entrypoint: main
+    templates:
+    - name: main
+      container:
+        image: busybox
+        args: ["{{ "{{inputs.parameters.message}}" }}"]
+`
+	runner, fs := newTestRunner(t, WithVariables(map[string]any{"namespace": "production", "job": "default"}))
+	require.NoError(t, fs.WriteFile("/config/base.yaml", []byte(baseManifest), 0644))
+
+	unit := config.Unit{
+		BaseManifestPath: strPtr("base.yaml"),
+		OutputDirectory:  "output",
+		APIVersion:       config.APIVersionV1Alpha1,
+		TemplateBase:     true,
+		PreserveComments: true,
+		Values: []config.Value{
+			{Filename: "backup", Vars: map[string]any{"job": "backup"}},
+			{Filename: "report", Vars: map[string]any{"job": "report", "namespace": "analytics"}},
+			{Filename: "cleanup"},
+		},
+	}
+	require.NoError(t, runner.processUnit(context.Background(), unit, "/config"))
+
+	for _, tc := range []struct {
+		filename  string
+		name      string
+		namespace string
+	}{
+		{filename: "backup", name: "backup", namespace: "production"},
+		{filename: "report", name: "report", namespace: "analytics"},
+		{filename: "cleanup", name: "default", namespace: "production"},
+	} {
+		content, err := fs.ReadFile("/config/output/" + tc.filename + ".yaml")
+		require.NoError(t, err)
+		var cw argoworkflowsv1alpha1.CronWorkflow
+		require.NoError(t, kyaml.Unmarshal(content, &cw))
+		assert.Equal(t, tc.name, cw.Name)
+		assert.Equal(t, tc.namespace, cw.Namespace)
+		assert.Equal(t, []string{"{{inputs.parameters.message}}"}, cw.Spec.WorkflowSpec.Templates[0].Container.Args)
+		assert.Contains(t, string(content), "# namespace of the environment")
+	}
+
+	t.Run("base manifests are read raw without templateBase", func(t *testing.T) {
+		unit := unit
+		unit.TemplateBase = false
+		err := runner.processUnit(context.Background(), unit, "/config")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to unmarshal base manifest file /config/base.yaml")
+	})
+
+	t.Run("missing values fail without strict mode", func(t *testing.T) {
+		runner, fs := newTestRunner(t)
+		require.NoError(t, fs.WriteFile("/config/base.yaml", []byte("metadata:\n  name: {{ .Var.job | default \"job\" }}\n  namespace: {{ .Var.namespace }}\n"), 0644))
+		unit := config.Unit{
+			BaseManifestPath: strPtr("base.yaml"),
+			OutputDirectory:  "output",
+			APIVersion:       config.APIVersionV1Alpha1,
+			TemplateBase:     true,
+			Values:           []config.Value{{Filename: "backup"}},
+		}
+		err := runner.processUnit(context.Background(), unit, "/config")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load base manifest for backup")
+		assert.Contains(t, err.Error(), `template: /config/base.yaml:3:16: executing "/config/base.yaml" at <strictValue ".Var.namespace">: error calling strictValue: .Var.namespace has no value`)
+	})
+
+	t.Run("template error names the base manifest and value", func(t *testing.T) {
+		runner, fs := newTestRunner(t, WithStrictTemplates(true))
+		require.NoError(t, fs.WriteFile("/config/base.yaml", []byte("metadata:\n  name: {{ .Var.job }}\n"), 0644))
+		unit := config.Unit{
+			BaseManifestPath: strPtr("base.yaml"),
+			OutputDirectory:  "output",
+			TemplateBase:     true,
+			Values:           []config.Value{{Filename: "backup"}},
+		}
+		err := runner.processUnit(context.Background(), unit, "/config")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load base manifest for backup")
+		assert.Contains(t, err.Error(), `template: /config/base.yaml:2:15: executing "/config/base.yaml" at <.Var.job>: map has no entry for key "job"`)
+	})
+}
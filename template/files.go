@@ -0,0 +1,153 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// WithAllowedEnv makes the environment variables with the given names available to config
+// templates as .Env and through the env function. Names can be patterns such as CI_*.
+// Other environment variables are never exposed, so that templates cannot leak secrets.
+func WithAllowedEnv(patterns ...string) Option {
+	return func(tr *TemplateRenderer) {
+		tr.allowedEnv = append(tr.allowedEnv, patterns...)
+	}
+}
+
+// environment returns the allowed environment variables that are set
+func (tr *TemplateRenderer) environment() (map[string]string, error) {
+	env := map[string]string{}
+	for _, pattern := range tr.allowedEnv {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid environment variable pattern %q: %w", pattern, err)
+		}
+	}
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		for _, pattern := range tr.allowedEnv {
+			if matched, _ := path.Match(pattern, name); matched {
+				env[name] = value
+				break
+			}
+		}
+	}
+	return env, nil
+}
+
+// envFunc returns the env function, which looks a variable up in the allowed environment
+// variables. Unlike .Env, it fails for variables that are not allowed instead of rendering nothing.
+func envFunc(env map[string]string) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		value, ok := env[name]
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set or not allowed", name)
+		}
+		return value, nil
+	}
+}
+
+// fileFuncs returns the readFile and glob functions, which only access files under baseDir.
+// If baseDir is empty, the functions fail as files are only available in config templates.
+func (tr *TemplateRenderer) fileFuncs(baseDir string) template.FuncMap {
+	return template.FuncMap{
+		"readFile": func(name string) (string, error) {
+			file, err := sandboxPath(baseDir, name)
+			if err != nil {
+				return "", fmt.Errorf("readFile: %w", err)
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return "", fmt.Errorf("readFile: %w", err)
+			}
+			tr.recordAccess(file)
+			return string(content), nil
+		},
+		"glob": func(pattern string) ([]string, error) {
+			if _, err := sandboxPath(baseDir, pattern); err != nil {
+				return nil, fmt.Errorf("glob: %w", err)
+			}
+			matches, err := filepath.Glob(filepath.Join(baseDir, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("glob: invalid pattern %q: %w", pattern, err)
+			}
+			files := make([]string, 0, len(matches))
+			for _, match := range matches {
+				rel, err := filepath.Rel(baseDir, match)
+				if err != nil {
+					return nil, fmt.Errorf("glob: %w", err)
+				}
+				// Matches can still leave the tree through symbolic links
+				if _, err := sandboxPath(baseDir, rel); err != nil {
+					return nil, fmt.Errorf("glob: %w", err)
+				}
+				tr.recordAccess(match)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			sort.Strings(files)
+			return files, nil
+		},
+	}
+}
+
+// sandboxPath resolves a path relative to baseDir and fails if it is absolute or leads outside
+// baseDir, including through symbolic links
+func sandboxPath(baseDir, name string) (string, error) {
+	if baseDir == "" {
+		return "", fmt.Errorf("files can only be read in config templates")
+	}
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("path %q must be relative to the config directory", name)
+	}
+	file := filepath.Join(baseDir, name)
+	if !isWithin(baseDir, file) {
+		return "", fmt.Errorf("path %q is outside the config directory", name)
+	}
+
+	// Symbolic links are checked on the real paths; paths that do not exist are left to the caller
+	realBase, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return file, nil
+	}
+	realFile, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return file, nil
+	}
+	if !isWithin(realBase, realFile) {
+		return "", fmt.Errorf("path %q is outside the config directory", name)
+	}
+	return file, nil
+}
+
+// isWithin reports whether file is dir or inside it
+func isWithin(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// recordAccess records a file read while rendering
+func (tr *TemplateRenderer) recordAccess(file string) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	tr.accessedFiles[file] = true
+}
+
+// AccessedFiles returns the absolute paths of the config, values and template files and the files
+// read by readFile and glob so far, in sorted order. A rendered config only changes if one of these
+// files, or the allowed environment variables, changes.
+func (tr *TemplateRenderer) AccessedFiles() []string {
+	files := make([]string, 0, len(tr.accessedFiles))
+	for file := range tr.accessedFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
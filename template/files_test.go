@@ -0,0 +1,148 @@
+package template
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRenderer_Env(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	t.Setenv("GIT_SHA", "abc123")
+	t.Setenv("CI_PIPELINE_ID", "42")
+	t.Setenv("SECRET_TOKEN", "hunter2")
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+
+	testCases := []struct {
+		name          string
+		allowed       []string
+		template      string
+		expected      string
+		errorContains string
+	}{
+		{name: "Env field", allowed: []string{"GIT_SHA"}, template: "{{ .Env.GIT_SHA }}", expected: "abc123"},
+		{name: "env function", allowed: []string{"GIT_SHA"}, template: `{{ env "GIT_SHA" | trunc 3 }}`, expected: "abc"},
+		{name: "pattern", allowed: []string{"CI_*"}, template: `{{ env "CI_PIPELINE_ID" }}`, expected: "42"},
+		{name: "variables that are not allowed are not exposed", allowed: []string{"GIT_SHA", "CI_*"}, template: `{{ len .Env }}{{ index .Env "SECRET_TOKEN" }}`, expected: "2"},
+		{name: "env of variable that is not allowed", allowed: []string{"GIT_SHA"}, template: `{{ env "SECRET_TOKEN" }}`, errorContains: `environment variable "SECRET_TOKEN" is not set or not allowed`},
+		{name: "env of allowed variable that is not set", allowed: []string{"UNSET_VARIABLE"}, template: `{{ env "UNSET_VARIABLE" }}`, errorContains: `environment variable "UNSET_VARIABLE" is not set or not allowed`},
+		{name: "invalid pattern", allowed: []string{"CI_["}, template: "x", errorContains: `invalid environment variable pattern "CI_["`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(tc.template), 0644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+			renderer := New(logger, WithAllowedEnv(tc.allowed...))
+			result, err := renderer.RenderConfigWithVariables(configPath, map[string]any{})
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, string(result))
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_FileFunctions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	rootDir := t.TempDir()
+	configDir := filepath.Join(rootDir, "config")
+	files := map[string]string{
+		"config/scripts/backup.sh":  "#!/bin/sh\necho backup",
+		"config/scripts/cleanup.sh": "echo cleanup",
+		"config/scripts/README.md":  "docs",
+		"secret.txt":                "hunter2",
+	}
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(rootDir, "secret.txt"), filepath.Join(configDir, "link.txt")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.yaml")
+
+	testCases := []struct {
+		name          string
+		template      string
+		expected      string
+		errorContains string
+	}{
+		{name: "readFile", template: `source: {{ readFile "scripts/backup.sh" | nindent 2 }}`, expected: "source: \n  #!/bin/sh\n  echo backup"},
+		{name: "readFile with dot segments inside the tree", template: `{{ readFile "scripts/../scripts/cleanup.sh" }}`, expected: "echo cleanup"},
+		{name: "glob", template: `{{ range glob "scripts/*.sh" }}{{ . }}={{ readFile . }};{{ end }}`, expected: "scripts/backup.sh=#!/bin/sh\necho backup;scripts/cleanup.sh=echo cleanup;"},
+		{name: "readFile outside the tree", template: `{{ readFile "../secret.txt" }}`, errorContains: `readFile: path "../secret.txt" is outside the config directory`},
+		{name: "readFile absolute path", template: `{{ readFile "/etc/passwd" }}`, errorContains: `readFile: path "/etc/passwd" must be relative to the config directory`},
+		{name: "readFile through symlink", template: `{{ readFile "link.txt" }}`, errorContains: `readFile: path "link.txt" is outside the config directory`},
+		{name: "readFile missing file", template: `{{ readFile "scripts/missing.sh" }}`, errorContains: "readFile: open"},
+		{name: "glob outside the tree", template: `{{ glob "../*.txt" }}`, errorContains: `glob: path "../*.txt" is outside the config directory`},
+		{name: "glob matching symlink", template: `{{ glob "*.txt" }}`, errorContains: `glob: path "link.txt" is outside the config directory`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(tc.template), 0644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+			renderer := New(logger)
+			result, err := renderer.RenderConfigWithVariables(configPath, map[string]any{})
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, string(result))
+			}
+		})
+	}
+
+	t.Run("accessed files", func(t *testing.T) {
+		if err := os.WriteFile(configPath, []byte(`{{ readFile "scripts/backup.sh" }}{{ glob "scripts/c*.sh" }}`), 0644); err != nil {
+			t.Fatalf("Failed to create config file: %v", err)
+		}
+		renderer := New(logger)
+		if _, err := renderer.RenderConfigWithVariables(configPath, map[string]any{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []string{
+			configPath,
+			filepath.Join(configDir, "scripts/backup.sh"),
+			filepath.Join(configDir, "scripts/cleanup.sh"),
+		}
+		if files := renderer.AccessedFiles(); !reflect.DeepEqual(files, expected) {
+			t.Errorf("Expected %v, got %v", expected, files)
+		}
+	})
+
+	t.Run("files are not available to value templates", func(t *testing.T) {
+		_, err := New(logger).RenderString("filename", `{{ readFile "scripts/backup.sh" }}`, map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "readFile: files can only be read in config templates") {
+			t.Errorf("Expected error for readFile outside config templates, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// funcMap returns the functions available in templates. vars are the variables the template is
+// rendered against, which the one-argument form of required looks keys up in.
+func funcMap(vars map[string]any) template.FuncMap {
+	return template.FuncMap{
+		// Strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"trunc":      trunc,
+		"quote":      quote,
+		"squote":     squote,
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"toString":   toString,
+
+		// Defaults and checks
+		"default":  defaultValue,
+		"empty":    isEmpty,
+		"coalesce": coalesce,
+		"ternary":  ternary,
+		"required": func(args ...any) (any, error) { return required(vars, args...) },
+
+		// Encoding
+		"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":    b64dec,
+		"sha256sum": sha256sum,
+		"toYaml":    toYaml,
+		"toJson":    toJSON,
+
+		// Lists
+		"list":      func(items ...any) []any { return items },
+		"first":     first,
+		"last":      last,
+		"append":    appendItem,
+		"has":       has,
+		"join":      join,
+		"uniq":      uniq,
+		"sortAlpha": sortAlpha,
+
+		// Dicts
+		"dict":   dict,
+		"get":    func(d map[string]any, key string) any { return d[key] },
+		"hasKey": func(d map[string]any, key string) bool { _, ok := d[key]; return ok },
+		"keys":   keys,
+		"dig":    dig,
+	}
+}
+
+// toString formats any value as a string
+func toString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// trunc shortens s to at most n characters
+func trunc(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// quote wraps each argument in double quotes, escaping as needed, and joins them with spaces
+func quote(args ...any) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, strconv.Quote(toString(arg)))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// squote wraps each argument in single quotes and joins them with spaces
+func squote(args ...any) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+toString(arg)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// indent prefixes every line of s with the given number of spaces
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// isEmpty reports whether value is nil or the zero value of its type, including empty lists and maps
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// defaultValue returns given unless it is empty, in which case it returns def.
+// given is optional so that a missing value piped into default works: {{ .Var.x | default "y" }}
+func defaultValue(def any, given ...any) any {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// coalesce returns the first argument that is not empty
+func coalesce(values ...any) any {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// ternary returns ifTrue if condition is true and ifFalse otherwise
+func ternary(ifTrue, ifFalse any, condition bool) any {
+	if condition {
+		return ifTrue
+	}
+	return ifFalse
+}
+
+// required fails the rendering if a value is missing. With one argument it looks up a variable by
+// its dot-separated key and names the key in the error. With two arguments, like Helm, it returns
+// the second argument or fails with the message given as the first.
+func required(vars map[string]any, args ...any) (any, error) {
+	switch len(args) {
+	case 1:
+		key, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("required expects a variable name, got %v", args[0])
+		}
+		value, exists := lookupVariable(vars, key)
+		if !exists || isEmpty(value) {
+			return nil, fmt.Errorf("required variable %q is not set", key)
+		}
+		return value, nil
+	case 2:
+		if isEmpty(args[1]) {
+			return nil, fmt.Errorf("%s", toString(args[0]))
+		}
+		return args[1], nil
+	}
+	return nil, fmt.Errorf("required expects 1 or 2 arguments, got %d", len(args))
+}
+
+// lookupVariable returns the variable with the given dot-separated key
+func lookupVariable(vars map[string]any, key string) (any, bool) {
+	var current any = vars
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// dig returns the value at the given keys of a nested dict, or the default if any key is missing:
+// {{ dig "image" "tag" "latest" .Var }}. Unlike .Var.image.tag it does not fail in strict mode.
+func dig(args ...any) (any, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("dig expects at least one key, a default and a dict, got %d arguments", len(args))
+	}
+	def := args[len(args)-2]
+	current, ok := args[len(args)-1].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("dig expects a dict as the last argument, got %T", args[len(args)-1])
+	}
+	keys := args[:len(args)-2]
+	for i, k := range keys {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("dig expects string keys, got %v", k)
+		}
+		value, exists := current[key]
+		if !exists || value == nil {
+			return def, nil
+		}
+		if i == len(keys)-1 {
+			return value, nil
+		}
+		if current, ok = value.(map[string]any); !ok {
+			return def, nil
+		}
+	}
+	return def, nil
+}
+
+// b64dec decodes a standard base64 string
+func b64dec(s string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("b64dec: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// sha256sum returns the hex encoded SHA-256 digest of s
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// toYaml encodes value as YAML without the trailing newline, for use with indent and nindent
+func toYaml(value any) (string, error) {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("toYaml: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// toJSON encodes value as compact JSON
+func toJSON(value any) (string, error) {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("toJson: %w", err)
+	}
+	return string(out), nil
+}
+
+// toList converts any slice to []any
+func toList(list any) ([]any, error) {
+	if list == nil {
+		return nil, nil
+	}
+	if items, ok := list.([]any); ok {
+		return items, nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", list)
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// first returns the first element of a list, or nil if it is empty
+func first(list any) (any, error) {
+	items, err := toList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+// last returns the last element of a list, or nil if it is empty
+func last(list any) (any, error) {
+	items, err := toList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[len(items)-1], nil
+}
+
+// appendItem returns a new list with item added at the end
+func appendItem(list any, item any) ([]any, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, 0, len(items)+1)
+	return append(append(result, items...), item), nil
+}
+
+// has reports whether the list contains needle
+func has(needle any, list any) (bool, error) {
+	items, err := toList(list)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		if reflect.DeepEqual(item, needle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// join joins the string forms of the list elements with sep
+func join(sep string, list any) (string, error) {
+	items, err := toList(list)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, toString(item))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// uniq returns the list without duplicate elements, keeping the first occurrence
+func uniq(list any) ([]any, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	var result []any
+	for _, item := range items {
+		duplicate := false
+		for _, seen := range result {
+			if reflect.DeepEqual(item, seen) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// sortAlpha returns the string forms of the list elements in sorted order
+func sortAlpha(list any) ([]string, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, toString(item))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// dict builds a map from alternating keys and values
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict expects an even number of arguments, got %d", len(pairs))
+	}
+	result := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %v", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+// keys returns the keys of a map in sorted order
+func keys(d map[string]any) []string {
+	result := make([]string, 0, len(d))
+	for key := range d {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
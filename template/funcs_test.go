@@ -0,0 +1,139 @@
+package template
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRenderer_Funcs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+	vars := map[string]any{
+		"name":    "Backup Job",
+		"empty":   "",
+		"zero":    0,
+		"regions": []any{"us-east-1", "ap-northeast-1", "us-east-1"},
+		"image":   map[string]any{"registry": "registry.example.com", "tag": "v1"},
+		"script":  "echo one\necho two",
+	}
+
+	testCases := []struct {
+		name          string
+		template      string
+		expected      string
+		errorContains string
+	}{
+		{name: "upper", template: `{{ upper .name }}`, expected: "BACKUP JOB"},
+		{name: "lower", template: `{{ .name | lower }}`, expected: "backup job"},
+		{name: "trim", template: `{{ trim "  x  " }}`, expected: "x"},
+		{name: "trimPrefix", template: `{{ trimPrefix "registry." .image.registry }}`, expected: "example.com"},
+		{name: "trimSuffix", template: `{{ .image.registry | trimSuffix ".com" }}`, expected: "registry.example"},
+		{name: "replace", template: `{{ .name | lower | replace " " "-" }}`, expected: "backup-job"},
+		{name: "contains", template: `{{ contains "Job" .name }}`, expected: "true"},
+		{name: "hasPrefix", template: `{{ hasPrefix "Backup" .name }}`, expected: "true"},
+		{name: "hasSuffix", template: `{{ hasSuffix "Backup" .name }}`, expected: "false"},
+		{name: "trunc", template: `{{ trunc 6 .name }}`, expected: "Backup"},
+		{name: "trunc longer than string", template: `{{ trunc 60 .name }}`, expected: "Backup Job"},
+		{name: "quote", template: `{{ quote .name "a\"b" }}`, expected: `"Backup Job" "a\"b"`},
+		{name: "squote", template: `{{ .zero | squote }}`, expected: `'0'`},
+		{name: "indent", template: `{{ indent 2 .script }}`, expected: "  echo one\n  echo two"},
+		{name: "nindent", template: `script:{{ .script | nindent 2 }}`, expected: "script:\n  echo one\n  echo two"},
+		{name: "toString", template: `{{ toString .zero | printf "%q" }}`, expected: `"0"`},
+		{name: "default on missing", template: `{{ .missing | default "fallback" }}`, expected: "fallback"},
+		{name: "default on empty string", template: `{{ default "fallback" .empty }}`, expected: "fallback"},
+		{name: "default on zero", template: `{{ .zero | default 5 }}`, expected: "5"},
+		{name: "default keeps value", template: `{{ .name | default "fallback" }}`, expected: "Backup Job"},
+		{name: "default on missing nested", template: `{{ .image.digest | default "latest" }}`, expected: "latest"},
+		{name: "empty", template: `{{ empty .empty }} {{ empty .regions }}`, expected: "true false"},
+		{name: "coalesce", template: `{{ coalesce .missing .empty .name }}`, expected: "Backup Job"},
+		{name: "ternary", template: `{{ ternary "prod" "dev" (eq .image.tag "v1") }}`, expected: "prod"},
+		{name: "required by key", template: `{{ required "image.tag" }}`, expected: "v1"},
+		{name: "required by key missing", template: `{{ required "image.digest" }}`, errorContains: `required variable "image.digest" is not set`},
+		{name: "required by key empty", template: `{{ required "empty" }}`, errorContains: `required variable "empty" is not set`},
+		{name: "required with message", template: `{{ required "name is required" .name }}`, expected: "Backup Job"},
+		{name: "required with message missing", template: `{{ required "owner must be set for every job" .owner }}`, errorContains: "error calling required: owner must be set for every job"},
+		{name: "required without arguments", template: `{{ required }}`, errorContains: "required expects 1 or 2 arguments, got 0"},
+		{name: "b64enc", template: `{{ b64enc "hello" }}`, expected: "aGVsbG8="},
+		{name: "b64dec", template: `{{ b64dec "aGVsbG8=" }}`, expected: "hello"},
+		{name: "b64dec invalid", template: `{{ b64dec "!!" }}`, errorContains: "b64dec: illegal base64 data"},
+		{name: "sha256sum", template: `{{ sha256sum "hello" }}`, expected: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "toYaml", template: `{{ toYaml .image }}`, expected: "registry: registry.example.com\ntag: v1"},
+		{name: "toYaml with nindent", template: `image:{{ toYaml .image | nindent 2 }}`, expected: "image:\n  registry: registry.example.com\n  tag: v1"},
+		{name: "toJson", template: `{{ toJson .image }}`, expected: `{"registry":"registry.example.com","tag":"v1"}`},
+		{name: "list", template: `{{ list 1 "a" true | toJson }}`, expected: `[1,"a",true]`},
+		{name: "first and last", template: `{{ first .regions }} {{ last .regions }}`, expected: "us-east-1 us-east-1"},
+		{name: "first of empty list", template: `{{ first list | default "none" }}`, expected: "none"},
+		{name: "append", template: `{{ append .regions "eu-west-1" | len }} {{ len .regions }}`, expected: "4 3"},
+		{name: "has", template: `{{ has "ap-northeast-1" .regions }} {{ .regions | has "eu-west-1" }}`, expected: "true false"},
+		{name: "join", template: `{{ join "," .regions }}`, expected: "us-east-1,ap-northeast-1,us-east-1"},
+		{name: "uniq", template: `{{ uniq .regions | join "," }}`, expected: "us-east-1,ap-northeast-1"},
+		{name: "sortAlpha", template: `{{ sortAlpha .regions | join "," }}`, expected: "ap-northeast-1,us-east-1,us-east-1"},
+		{name: "list function on non-list", template: `{{ first .name }}`, errorContains: "expected a list, got string"},
+		{name: "dict", template: `{{ dict "b" 2 "a" .name | toJson }}`, expected: `{"a":"Backup Job","b":2}`},
+		{name: "dict with odd arguments", template: `{{ dict "a" }}`, errorContains: "dict expects an even number of arguments, got 1"},
+		{name: "get and hasKey", template: `{{ get .image "tag" }} {{ hasKey .image "digest" }}`, expected: "v1 false"},
+		{name: "dig", template: `{{ dig "image" "tag" "latest" . }}`, expected: "v1"},
+		{name: "dig missing key", template: `{{ dig "image" "digest" "none" . }} {{ dig "owner" "name" "none" . }}`, expected: "none none"},
+		{name: "dig through non-dict", template: `{{ dig "name" "first" "none" . }}`, expected: "none"},
+		{name: "dig without dict", template: `{{ dig "a" "b" "c" }}`, errorContains: "dig expects a dict as the last argument, got string"},
+		{name: "keys", template: `{{ keys .image | join "," }}`, expected: "registry,tag"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := renderer.RenderString("test", tc.template, vars)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_RenderConfig_RequiredNamesMissingKey(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+
+	tempDir := t.TempDir()
+	configPath := tempDir + "/config.yaml"
+	valuesPath := tempDir + "/values.yaml"
+	configContent := "units:\n  - outputDirectory: {{ required \"outputBase\" }}\n    apiVersion: {{ .Var.apiVersion | default \"v1alpha1\" | quote }}\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	if err := os.WriteFile(valuesPath, []byte("environment: test\n"), 0644); err != nil {
+		t.Fatalf("Failed to create values file: %v", err)
+	}
+
+	_, err := renderer.RenderConfig(configPath, valuesPath)
+	if err == nil {
+		t.Fatal("Expected error for missing required value, but got nil")
+	}
+	for _, expected := range []string{"config:2:24", `required variable "outputBase" is not set`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+
+	if err := os.WriteFile(valuesPath, []byte("outputBase: ./output\n"), 0644); err != nil {
+		t.Fatalf("Failed to update values file: %v", err)
+	}
+	result, err := renderer.RenderConfig(configPath, valuesPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "units:\n  - outputDirectory: ./output\n    apiVersion: \"v1alpha1\"\n"
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(result))
+	}
+}
@@ -0,0 +1,149 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// partialExtension is the extension of template files loaded from a templates directory
+const partialExtension = ".tpl"
+
+// maxIncludeDepth limits nested include calls, so that a partial including itself fails instead of
+// overflowing the stack
+const maxIncludeDepth = 100
+
+// partial is a template file whose definitions are available to the rendered template
+type partial struct {
+	name    string // path relative to the config directory, used in error messages and by {{ template }}
+	content string
+}
+
+// WithTemplates makes the templates defined in the given directories or glob patterns available
+// to config templates through {{ include }} and {{ template }}. A directory loads its *.tpl files.
+// Relative paths are resolved against the directory of the config file.
+func WithTemplates(patterns ...string) Option {
+	return func(tr *TemplateRenderer) {
+		tr.templatePatterns = append(tr.templatePatterns, patterns...)
+	}
+}
+
+// loadPartials reads the template files matched by the renderer's patterns, resolving relative
+// patterns against baseDir
+func (tr *TemplateRenderer) loadPartials(baseDir string) ([]partial, error) {
+	var partials []partial
+	seen := map[string]bool{}
+	for _, pattern := range tr.templatePatterns {
+		files, err := resolveTemplateFiles(baseDir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template file %s: %w", file, err)
+			}
+			tr.recordAccess(file)
+			name := file
+			if rel, err := relativePath(baseDir, file); err == nil {
+				name = rel
+			}
+			partials = append(partials, partial{name: name, content: string(content)})
+		}
+	}
+
+	tr.logger.Debug("loaded template files", "baseDir", baseDir, "templateFileCount", len(partials))
+	return partials, nil
+}
+
+// relativePath returns file relative to baseDir, where either can be absolute or relative to the
+// working directory
+func relativePath(baseDir, file string) (string, error) {
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absBase, absFile)
+}
+
+// resolveTemplateFiles returns the files of a templates directory or glob pattern in sorted order
+func resolveTemplateFiles(baseDir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		files, err := filepath.Glob(filepath.Join(pattern, "*"+partialExtension))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates directory %s: %w", pattern, err)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates pattern %s: %w", pattern, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no template files match %s", pattern)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// parsePartials adds the partials to the template set of tmpl
+func parsePartials(tmpl *template.Template, partials []partial) error {
+	for _, p := range partials {
+		if _, err := tmpl.New(p.name).Parse(p.content); err != nil {
+			return fmt.Errorf("failed to parse template file %s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// includeDepthError is returned when includes are nested too deeply. It is passed through the
+// enclosing includes as-is, rather than wrapped at every level.
+type includeDepthError struct {
+	name string
+}
+
+func (e *includeDepthError) Error() string {
+	return fmt.Sprintf("include %q: too many nested includes, is a template including itself?", e.name)
+}
+
+// includeFunc returns the include function for the template set of *tmpl, which executes a named
+// template and returns its output as a string so that it can be piped, e.g. into nindent
+func includeFunc(tmpl **template.Template) func(name string, data any) (string, error) {
+	depth := 0
+	return func(name string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", &includeDepthError{name: name}
+		}
+		depth++
+		defer func() { depth-- }()
+
+		var buf bytes.Buffer
+		if err := (*tmpl).ExecuteTemplate(&buf, name, data); err != nil {
+			var depthErr *includeDepthError
+			if errors.As(err, &depthErr) {
+				return "", depthErr
+			}
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
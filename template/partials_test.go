@@ -0,0 +1,127 @@
+package template
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRenderer_RenderConfig_Templates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"templates/retry.tpl": `{{- define "retryPaths" -}}
+- path: "$.spec.workflowSpec.retryStrategy.limit"
+  value: {{ .limit }}
+{{- end }}
+{{- define "name" }}{{ .Var.team }}-{{ .job }}{{ end }}`,
+		"templates/ignored.yaml": `{{ define "ignored" }}not loaded{{ end }}`,
+		"shared/schedule.tpl":    `{{ define "schedule" }}"0 {{ . }} * * *"{{ end }}`,
+		"values.yaml":            "team: data\n",
+		"config.yaml": `units:
+  - outputDirectory: ./output
+    values:
+      - filename: {{ include "name" (dict "Var" .Var "job" "backup") }}
+        paths:
+          - path: "$.spec.schedule"
+            value: {{ template "schedule" 3 }}
+{{ include "retryPaths" (dict "limit" 2) | indent 10 }}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	configPath := filepath.Join(tempDir, "config.yaml")
+	valuesPath := filepath.Join(tempDir, "values.yaml")
+
+	renderer := New(logger, WithTemplates("templates", "shared/*.tpl"))
+	result, err := renderer.RenderConfig(configPath, valuesPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `units:
+  - outputDirectory: ./output
+    values:
+      - filename: data-backup
+        paths:
+          - path: "$.spec.schedule"
+            value: "0 3 * * *"
+          - path: "$.spec.workflowSpec.retryStrategy.limit"
+            value: 2
+`
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(result))
+	}
+
+	testCases := []struct {
+		name          string
+		patterns      []string
+		config        string
+		errorContains string
+	}{
+		{
+			name:          "undefined template",
+			patterns:      []string{"templates"},
+			config:        `{{ include "missing" . }}`,
+			errorContains: `no template "missing"`,
+		},
+		{
+			name:          "only tpl files are loaded from a directory",
+			patterns:      []string{"templates"},
+			config:        `{{ include "ignored" . }}`,
+			errorContains: `no template "ignored"`,
+		},
+		{
+			name:          "glob matching nothing",
+			patterns:      []string{"partials/*.tpl"},
+			config:        `units: []`,
+			errorContains: "no template files match " + filepath.Join(tempDir, "partials/*.tpl"),
+		},
+		{
+			name:          "recursive include",
+			patterns:      []string{"loop/*.tpl"},
+			config:        `{{ include "loop" . }}`,
+			errorContains: `include "loop": too many nested includes`,
+		},
+		{
+			name:          "parse error names the template file",
+			patterns:      []string{"broken/*.tpl"},
+			config:        `units: []`,
+			errorContains: "failed to parse template file broken/bad.tpl",
+		},
+	}
+	for name, content := range map[string]string{
+		"loop/loop.tpl":  `{{ define "loop" }}{{ include "loop" . }}{{ end }}`,
+		"broken/bad.tpl": `{{ define "bad" }}{{ .x `,
+	} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(tc.config), 0644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+			renderer := New(logger, WithTemplates(tc.patterns...))
+			_, err := renderer.RenderConfig(configPath, valuesPath)
+			if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+				t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+			}
+		})
+	}
+}
@@ -6,54 +6,92 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"gopkg.in/yaml.v3"
 )
 
 // TemplateRenderer provides template rendering functionality for config files
 type TemplateRenderer struct {
-	logger *slog.Logger
+	logger           *slog.Logger
+	strict           bool
+	noValueCheck     bool            // fail on actions that print "<no value>", see WithNoValueCheck
+	templatePatterns []string        // directories or glob patterns of template files, see WithTemplates
+	allowedEnv       []string        // names or patterns of environment variables, see WithAllowedEnv
+	accessedFiles    map[string]bool // absolute paths of the files read while rendering, see AccessedFiles
 }
 
+// Option configures a TemplateRenderer
+type Option func(*TemplateRenderer)
+
 // TemplateData contains all variables available in templates
 type TemplateData struct {
-	Var map[string]any // Variables loaded from the values file
+	Var map[string]any    // Variables loaded from the values file
+	Env map[string]string // Allowed environment variables
+}
+
+// renderContext holds what the functions of a template need besides its data
+type renderContext struct {
+	vars     map[string]any    // variables that required looks keys up in
+	env      map[string]string // allowed environment variables for env
+	baseDir  string            // directory that readFile and glob are restricted to; empty if they are not available
+	partials []partial         // template files for include and template
 }
 
 // New creates a new TemplateRenderer instance
-func New(logger *slog.Logger) *TemplateRenderer {
-	return &TemplateRenderer{
-		logger: logger,
+func New(logger *slog.Logger, opts ...Option) *TemplateRenderer {
+	tr := &TemplateRenderer{
+		logger:        logger,
+		accessedFiles: map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(tr)
 	}
+	return tr
 }
 
-// RenderConfig renders a config file as a template using the provided values file
-func (tr *TemplateRenderer) RenderConfig(configPath, valuesPath string) ([]byte, error) {
-	// Read the config file (template)
-	configContent, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+// WithStrict makes rendering fail on missing variables instead of rendering "<no value>"
+func WithStrict(strict bool) Option {
+	return func(tr *TemplateRenderer) {
+		tr.strict = strict
+	}
+}
+
+// WithNoValueCheck makes rendering fail when "<no value>" would end up in the output, like strict
+// mode, but without failing on missing variables that are handled by the template, e.g. with default
+func WithNoValueCheck(check bool) Option {
+	return func(tr *TemplateRenderer) {
+		tr.noValueCheck = check
 	}
+}
 
+// RenderConfig renders a config file as a template using the provided values file
+func (tr *TemplateRenderer) RenderConfig(configPath, valuesPath string) ([]byte, error) {
 	// Load variables from the values file
-	variables, err := tr.loadVariables(valuesPath)
+	variables, err := tr.LoadVariables(valuesPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load variables from %s: %w", valuesPath, err)
 	}
 
-	// Create template data
-	templateData := &TemplateData{
-		Var: variables,
-	}
+	return tr.RenderConfigWithVariables(configPath, variables)
+}
 
-	// Render the template
-	renderedContent, err := tr.renderTemplate(string(configContent), templateData)
+// RenderConfigWithVariables renders a config file as a template using already loaded variables
+func (tr *TemplateRenderer) RenderConfigWithVariables(configPath string, variables map[string]any) ([]byte, error) {
+	// Read the config file (template)
+	configContent, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render template: %w", err)
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
 	}
+	tr.recordAccess(configPath)
 
-	return []byte(renderedContent), nil
+	// Files are resolved relative to the config
+	return tr.renderConfigContent(string(configContent), filepath.Dir(configPath), variables)
 }
 
 // RenderConfigFromReader renders a config template from an io.Reader using the provided values file
@@ -65,18 +103,40 @@ func (tr *TemplateRenderer) RenderConfigFromReader(configReader io.Reader, value
 	}
 
 	// Load variables from the values file
-	variables, err := tr.loadVariables(valuesPath)
+	variables, err := tr.LoadVariables(valuesPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load variables from %s: %w", valuesPath, err)
 	}
 
+	// Files are resolved relative to the working directory, as there is no config path
+	return tr.renderConfigContent(string(configContent), ".", variables)
+}
+
+// renderConfigContent renders config content as a template. Template files, readFile and glob are
+// resolved relative to baseDir.
+func (tr *TemplateRenderer) renderConfigContent(configContent, baseDir string, variables map[string]any) ([]byte, error) {
+	partials, err := tr.loadPartials(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	env, err := tr.environment()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create template data
 	templateData := &TemplateData{
 		Var: variables,
+		Env: env,
 	}
 
 	// Render the template
-	renderedContent, err := tr.renderTemplate(string(configContent), templateData)
+	renderedContent, err := tr.renderTemplate(configContent, templateData, renderContext{
+		vars:     variables,
+		env:      env,
+		baseDir:  baseDir,
+		partials: partials,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to render template: %w", err)
 	}
@@ -84,8 +144,8 @@ func (tr *TemplateRenderer) RenderConfigFromReader(configReader io.Reader, value
 	return []byte(renderedContent), nil
 }
 
-// loadVariables loads variables from a YAML file into a map[string]interface{}
-func (tr *TemplateRenderer) loadVariables(valuesPath string) (map[string]any, error) {
+// LoadVariables loads variables from a YAML file into a map[string]interface{}
+func (tr *TemplateRenderer) LoadVariables(valuesPath string) (map[string]any, error) {
 	valuesContent, err := os.ReadFile(valuesPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read values file: %w", err)
@@ -95,6 +155,7 @@ func (tr *TemplateRenderer) loadVariables(valuesPath string) (map[string]any, er
 	if err := yaml.Unmarshal(valuesContent, &variables); err != nil {
 		return nil, fmt.Errorf("failed to parse values file as YAML: %w", err)
 	}
+	tr.recordAccess(valuesPath)
 
 	tr.logger.Debug("loaded variables from values file",
 		"valuesPath", valuesPath,
@@ -103,23 +164,305 @@ func (tr *TemplateRenderer) loadVariables(valuesPath string) (map[string]any, er
 	return variables, nil
 }
 
-// renderTemplate renders a template string with the provided data
-func (tr *TemplateRenderer) renderTemplate(templateStr string, data *TemplateData) (string, error) {
-	tmpl, err := template.New("config").Parse(templateStr)
+// LoadDefaultValues returns the defaultValues declared at the top level of a config file, or nil if
+// it declares none. The config may be a template that is not valid YAML until it is rendered, so only
+// the defaultValues block is parsed, and it cannot contain template actions.
+func (tr *TemplateRenderer) LoadDefaultValues(configPath string) (map[string]any, error) {
+	configContent, err := os.ReadFile(configPath)
 	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+	}
+
+	block := topLevelBlock(string(configContent), "defaultValues")
+	if block == "" {
+		return nil, nil
+	}
+	if strings.Contains(block, "{{") {
+		return nil, fmt.Errorf("defaultValues of %s cannot contain template actions", configPath)
+	}
+
+	var defaults struct {
+		DefaultValues map[string]any `yaml:"defaultValues"`
+	}
+	if err := yaml.Unmarshal([]byte(block), &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse defaultValues of %s: %w", configPath, err)
+	}
+	tr.recordAccess(configPath)
+
+	tr.logger.Debug("loaded default values from config file",
+		"configPath", configPath,
+		"variableCount", len(defaults.DefaultValues))
+
+	return defaults.DefaultValues, nil
+}
+
+// LoadTemplatesDir returns the templatesDir declared at the top level of a config file, or "" if it
+// declares none. Like the patterns of WithTemplates, a relative templatesDir is relative to the
+// directory of the config file. Like defaultValues, it is read before the config is rendered and
+// cannot contain template actions.
+func (tr *TemplateRenderer) LoadTemplatesDir(configPath string) (string, error) {
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file %s: %w", configPath, err)
+	}
+
+	block := topLevelBlock(string(configContent), "templatesDir")
+	if block == "" {
+		return "", nil
+	}
+	if strings.Contains(block, "{{") {
+		return "", fmt.Errorf("templatesDir of %s cannot contain template actions", configPath)
+	}
+
+	var declared struct {
+		TemplatesDir string `yaml:"templatesDir"`
+	}
+	if err := yaml.Unmarshal([]byte(block), &declared); err != nil {
+		return "", fmt.Errorf("failed to parse templatesDir of %s: %w", configPath, err)
+	}
+	return declared.TemplatesDir, nil
+}
+
+// topLevelBlock returns the lines of a top-level key of the config: its own line and the
+// indented, blank and comment lines that follow it
+func topLevelBlock(configContent, key string) string {
+	lines := strings.Split(configContent, "\n")
+	start := slices.IndexFunc(lines, func(line string) bool {
+		return strings.HasPrefix(line, key+":")
+	})
+	if start < 0 {
+		return ""
+	}
+
+	end := start + 1
+	for end < len(lines) {
+		line := lines[end]
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "#") {
+			break
+		}
+		end++
+	}
+	return strings.Join(lines[start:end], "\n")
+}
+
+// LoadValuesFiles loads the given values files and deep-merges them in order, so later files
+// override earlier ones (see MergeVariables)
+func (tr *TemplateRenderer) LoadValuesFiles(valuesPaths []string) (map[string]any, error) {
+	layers := make([]map[string]any, 0, len(valuesPaths))
+	for _, valuesPath := range valuesPaths {
+		variables, err := tr.LoadVariables(valuesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load variables from %s: %w", valuesPath, err)
+		}
+		layers = append(layers, variables)
+	}
+	return MergeVariables(layers...), nil
+}
+
+// renderTemplate renders a template string with the provided data
+func (tr *TemplateRenderer) renderTemplate(templateStr string, data *TemplateData, ctx renderContext) (string, error) {
+	return tr.execute("config", templateStr, data, ctx)
+}
+
+// execute parses and executes a named template with the provided data and context
+func (tr *TemplateRenderer) execute(name, templateStr string, data any, ctx renderContext) (string, error) {
+	var tmpl *template.Template
+	tmpl = template.New(name).
+		Funcs(funcMap(ctx.vars)).
+		Funcs(tr.fileFuncs(ctx.baseDir)).
+		Funcs(template.FuncMap{"include": includeFunc(&tmpl), "env": envFunc(ctx.env)})
+	checkValues := tr.strict || tr.noValueCheck
+	if tr.strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	if checkValues {
+		tmpl = tmpl.Funcs(template.FuncMap{strictValueFunc: strictValue})
+	}
+	if err := parsePartials(tmpl, ctx.partials); err != nil {
+		return "", err
+	}
+	if _, err := tmpl.Parse(templateStr); err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
+	if checkValues {
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				checkActions(t.Root)
+			}
+		}
+	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
 
+	if checkValues {
+		if err := checkNoValue(name, templateStr, buf.String()); err != nil {
+			return "", err
+		}
+	}
+
 	return buf.String(), nil
 }
 
-// HasTemplateVars checks if the config content contains template variables
-// This is a simple heuristic check for template syntax
+// strictValueFunc is the function that checkActions appends to the actions of strict templates
+const strictValueFunc = "strictValue"
+
+// noValue is what text/template prints for missing and null values
+const noValue = "<no value>"
+
+// strictValue passes value through, or fails if it would be printed as "<no value>" or contains it,
+// e.g. a value that was itself rendered by a non-strict template.
+// expr is the original pipeline of the action, for the error message.
+func strictValue(expr string, value any) (any, error) {
+	if value == nil {
+		return nil, fmt.Errorf("%s has no value", expr)
+	}
+	if s, ok := value.(string); ok && strings.Contains(s, noValue) {
+		return nil, fmt.Errorf("%s contains %q: %s", expr, noValue, s)
+	}
+	return value, nil
+}
+
+// checkActions appends strictValue to every action in node that prints its value, so that a
+// missing or null value fails at the action and the error names its line and column. Missing map
+// keys are already caught by missingkey=error, but index, get and null values are not.
+func checkActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			checkActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			// Variable declarations print nothing
+			return
+		}
+		expr := n.Pipe.String()
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args: []parse.Node{
+				parse.NewIdentifier(strictValueFunc).SetPos(n.Pos),
+				&parse.StringNode{NodeType: parse.NodeString, Pos: n.Pos, Quoted: strconv.Quote(expr), Text: expr},
+			},
+		})
+	case *parse.IfNode:
+		checkActions(n.List)
+		checkActions(n.ElseList)
+	case *parse.RangeNode:
+		checkActions(n.List)
+		checkActions(n.ElseList)
+	case *parse.WithNode:
+		checkActions(n.List)
+		checkActions(n.ElseList)
+	}
+}
+
+// checkNoValue rejects rendered output that still contains "<no value>". Actions that print it
+// already fail in strictValue, so what is left comes from the text of the template itself, and the
+// error names the line of the template it appears on.
+func checkNoValue(name, templateStr, rendered string) error {
+	if !strings.Contains(rendered, noValue) {
+		return nil
+	}
+	for i, line := range strings.Split(templateStr, "\n") {
+		if strings.Contains(line, noValue) {
+			return fmt.Errorf("template: %s:%d: contains %q: %s", name, i+1, noValue, strings.TrimSpace(line))
+		}
+	}
+	// The text of a shared template
+	return fmt.Errorf("template: %s: rendered output contains %q", name, noValue)
+}
+
+// configTemplateFuncs are the functions that are only available in config templates
+var configTemplateFuncs = []string{"include", "env", "readFile", "glob"}
+
+// HasTemplateVars checks if the config content is a config template: it refers to .Var or .Env,
+// uses shared templates, or calls one of the functions only available in config templates.
+// Other {{...}} are rendered later and do not make a config a template: Argo expressions such as
+// {{inputs.parameters.message}} are not valid templates at all, and value variables such as
+// {{ .team }} are rendered per value.
 func HasTemplateVars(configContent string) bool {
-	return bytes.Contains([]byte(configContent), []byte("{{")) && bytes.Contains([]byte(configContent), []byte("}}"))
+	if !strings.Contains(configContent, "{{") || !strings.Contains(configContent, "}}") {
+		return false
+	}
+
+	funcs := funcMap(nil)
+	for _, name := range configTemplateFuncs {
+		funcs[name] = func(...any) any { return nil }
+	}
+	tmpl, err := template.New("config").Funcs(funcs).Parse(configContent)
+	if err != nil {
+		// A config template with a syntax error still refers to its values
+		return strings.Contains(configContent, ".Var") || strings.Contains(configContent, ".Env")
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Name() != tmpl.Name() || usesConfigData(t.Root) {
+			// Any {{ define }} makes the config a template too
+			return true
+		}
+	}
+	return false
+}
+
+// usesConfigData reports whether node refers to .Var or .Env, includes another template or calls a
+// function that is only available in config templates
+func usesConfigData(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if usesConfigData(child) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesConfigData(n.Pipe)
+	case *parse.IfNode:
+		return usesConfigData(n.Pipe) || usesConfigData(n.List) || usesConfigData(n.ElseList)
+	case *parse.RangeNode:
+		return usesConfigData(n.Pipe) || usesConfigData(n.List) || usesConfigData(n.ElseList)
+	case *parse.WithNode:
+		return usesConfigData(n.Pipe) || usesConfigData(n.List) || usesConfigData(n.ElseList)
+	case *parse.TemplateNode:
+		return true
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if usesConfigData(cmd) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if usesConfigData(arg) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return usesConfigData(n.Node)
+	case *parse.FieldNode:
+		return isConfigData(n.Ident[0])
+	case *parse.VariableNode:
+		// $.Var
+		return len(n.Ident) > 1 && n.Ident[0] == "$" && isConfigData(n.Ident[1])
+	case *parse.IdentifierNode:
+		return slices.Contains(configTemplateFuncs, n.Ident)
+	}
+	return false
+}
+
+// isConfigData reports whether field is one of the fields of TemplateData
+func isConfigData(field string) bool {
+	return field == "Var" || field == "Env"
 }
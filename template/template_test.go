@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -204,6 +205,66 @@ func TestHasTemplateVars(t *testing.T) {
 			content:  "",
 			expected: false,
 		},
+		{
+			name:     "argo expressions",
+			content:  `args: ["{{inputs.parameters.message}}", "{{workflow.name}}"]`,
+			expected: false,
+		},
+		{
+			name:     "value variables",
+			content:  `filename: "{{ .team }}-{{ .job | upper }}"`,
+			expected: false,
+		},
+		{
+			name:     "escaped argo expression in config template",
+			content:  `args: ["{{ "{{inputs.parameters.message}}" }}"]`,
+			expected: false,
+		},
+		{
+			name:     "Var inside range",
+			content:  "{{ range $region := .Var.regions }}\n- {{ $region }}\n{{ end }}",
+			expected: true,
+		},
+		{
+			name:     "Var through root variable",
+			content:  `{{ with .Var }}{{ $.Var.name }}{{ end }}`,
+			expected: true,
+		},
+		{
+			name:     "Var in function argument",
+			content:  `name: {{ required "name is required" .Var.name | quote }}`,
+			expected: true,
+		},
+		{
+			name:     "Env",
+			content:  `tag: {{ .Env.GIT_SHA }}`,
+			expected: true,
+		},
+		{
+			name:     "config template function",
+			content:  `source: {{ readFile "scripts/backup.sh" | quote }}`,
+			expected: true,
+		},
+		{
+			name:     "shared template",
+			content:  `{{ template "retryPaths" . }}`,
+			expected: true,
+		},
+		{
+			name:     "template definition",
+			content:  `{{ define "name" }}backup{{ end }}`,
+			expected: true,
+		},
+		{
+			name:     "syntax error in config template",
+			content:  `name: {{ .Var.name }`,
+			expected: false,
+		},
+		{
+			name:     "syntax error in config template with closing braces",
+			content:  `name: {{ .Var.name | }}`,
+			expected: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -215,3 +276,227 @@ func TestHasTemplateVars(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateRenderer_LoadDefaultValues(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+
+	testCases := []struct {
+		name          string
+		content       string
+		expected      map[string]any
+		errorContains string
+	}{
+		{
+			name: "defaultValues of a template",
+			content: `defaultValues:
+  # image tag
+  image:
+    tag: v1
+
+  regions: [us-east-1]
+units:
+{{- range .Var.regions }}
+  - outputDirectory: ./{{ . }}
+{{- end }}
+`,
+			expected: map[string]any{"image": map[string]any{"tag": "v1"}, "regions": []any{"us-east-1"}},
+		},
+		{
+			name:     "defaultValues after units",
+			content:  "units: []\ndefaultValues: {name: backup}\n",
+			expected: map[string]any{"name": "backup"},
+		},
+		{
+			name:     "no defaultValues",
+			content:  "units:\n  - outputDirectory: ./output\n    defaultValues: {}\n",
+			expected: nil,
+		},
+		{
+			name:          "template actions in defaultValues",
+			content:       "defaultValues:\n  name: {{ .Var.name }}\n",
+			errorContains: "cannot contain template actions",
+		},
+		{
+			name:          "invalid defaultValues",
+			content:       "defaultValues:\n  - a\n",
+			errorContains: "failed to parse defaultValues of",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+
+			result, err := renderer.LoadDefaultValues(configPath)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_LoadTemplatesDir(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+
+	testCases := []struct {
+		name          string
+		content       string
+		expected      string
+		errorContains string
+	}{
+		{
+			name:     "relative templatesDir",
+			content:  "templatesDir: shared/templates\nunits:\n  - {{ include \"unit\" . }}\n",
+			expected: "shared/templates",
+		},
+		{
+			name:     "absolute templatesDir",
+			content:  "templatesDir: /etc/replicator/templates\nunits: []\n",
+			expected: "/etc/replicator/templates",
+		},
+		{
+			name:     "no templatesDir",
+			content:  "units: []\n",
+			expected: "",
+		},
+		{
+			name:          "template actions in templatesDir",
+			content:       "templatesDir: {{ .Var.dir }}\n",
+			errorContains: "cannot contain template actions",
+		},
+		{
+			name:          "invalid templatesDir",
+			content:       "templatesDir: [a, b]\n",
+			errorContains: "failed to parse templatesDir of",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+
+			result, err := renderer.LoadTemplatesDir(configPath)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_Strict(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	vars := map[string]any{
+		"name":   "backup",
+		"owner":  nil,
+		"image":  map[string]any{"tag": "v1"},
+		"broken": "<no value>-backup",
+	}
+
+	testCases := []struct {
+		name          string
+		template      string
+		expected      string
+		errorContains string
+	}{
+		{name: "present variables", template: "name: {{ .name }}\ntag: {{ .image.tag }}", expected: "name: backup\ntag: v1"},
+		{name: "missing key", template: "name: {{ .name }}\ntag: {{ .image.digest }}", errorContains: `test:2:14: executing "test" at <.image.digest>: map has no entry for key "digest"`},
+		{name: "null value", template: "name: {{ .name }}\nowner: {{ .owner }}", errorContains: "test:2:10: executing \"test\" at <strictValue \".owner\">: error calling strictValue: .owner has no value"},
+		{name: "missing key of get", template: `{{ get .image "digest" }}`, errorContains: "test:1:3:"},
+		{name: "inside range", template: "{{ range $i, $k := list \"tag\" \"digest\" }}\n- {{ index $.image $k }}{{ end }}", errorContains: "test:2:5:"},
+		{name: "inside if else", template: "{{ if eq .name \"other\" }}{{ else }}{{ index .image \"x\" }}{{ end }}", errorContains: "has no value"},
+		{name: "variable declarations print nothing", template: `{{ $x := index .image "x" }}{{ $x | default "none" }}`, expected: "none"},
+		{name: "optional value with dig", template: `{{ dig "image" "digest" "latest" . }}`, expected: "latest"},
+		{name: "value containing no value", template: "name: ok\nbroken: {{ .broken }}", errorContains: `test:2:11: executing "test" at <strictValue ".broken">: error calling strictValue: .broken contains "<no value>": <no value>-backup`},
+		{name: "no value in the template text", template: "name: {{ .name }}\nowner: <no value>", errorContains: `test:2: contains "<no value>": owner: <no value>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := New(logger, WithStrict(true))
+			result, err := renderer.RenderString("test", tc.template, vars)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+
+	// Without strict mode, missing values are rendered as "<no value>" as before
+	result, err := New(logger).RenderString("test", "{{ .image.digest }}-{{ .owner }}", vars)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "<no value>-<no value>" {
+		t.Errorf("Expected %q, got %q", "<no value>-<no value>", result)
+	}
+}
+
+func TestTemplateRenderer_NoValueCheck(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	vars := map[string]any{"image": map[string]any{"tag": "v1"}}
+
+	testCases := []struct {
+		name          string
+		template      string
+		expected      string
+		errorContains string
+	}{
+		{name: "present variables", template: "tag: {{ .image.tag }}", expected: "tag: v1"},
+		{name: "missing key with default", template: `name: {{ .name | default "job" }}`, expected: "name: job"},
+		{name: "missing nested key with default", template: `digest: {{ .image.digest | default "none" }}`, expected: "digest: none"},
+		{name: "missing key", template: "tag: {{ .image.tag }}\nname: {{ .name }}", errorContains: `test:2:9: executing "test" at <strictValue ".name">: error calling strictValue: .name has no value`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := New(logger, WithNoValueCheck(true))
+			result, err := renderer.RenderString("test", tc.template, vars)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
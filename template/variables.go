@@ -0,0 +1,155 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeVariables deep-merges variable layers in order, so later layers override earlier ones.
+// Nested maps are merged key by key and any other value, including lists, replaces the previous one.
+// The layers are not modified.
+func MergeVariables(layers ...map[string]any) map[string]any {
+	result := map[string]any{}
+	for _, layer := range layers {
+		mergeInto(result, layer)
+	}
+	return result
+}
+
+// mergeInto deep-merges src into dst
+func mergeInto(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		switch {
+		case srcIsMap && dstIsMap:
+			mergeInto(dstMap, srcMap)
+		case srcIsMap:
+			copied := map[string]any{}
+			mergeInto(copied, srcMap)
+			dst[key] = copied
+		default:
+			dst[key] = value
+		}
+	}
+}
+
+// RenderValue renders every string in value as a template against vars, descending into maps and
+// lists. Strings without template actions are returned unchanged; other values are returned as-is.
+func (tr *TemplateRenderer) RenderValue(name string, value any, vars map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return tr.RenderString(name, v, vars)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, element := range v {
+			rendered, err := tr.RenderValue(name, element, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, element := range v {
+			rendered, err := tr.RenderValue(name, element, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// RenderString renders a single string as a template against vars, which are available as
+// top-level fields (e.g. {{ .team }}). name identifies the string in error messages.
+func (tr *TemplateRenderer) RenderString(name, text string, vars map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	rendered, err := tr.execute(name, text, vars, renderContext{vars: vars})
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return rendered, nil
+}
+
+// SetVariable applies a key=value assignment, as given to --set, to vars. The key is dot-separated
+// and intermediate maps are created as needed. The value is parsed as a YAML scalar, so numbers,
+// booleans and null keep their types, unless asString is true (--set-string).
+func SetVariable(vars map[string]any, assignment string, asString bool) error {
+	key, raw, found := strings.Cut(assignment, "=")
+	if !found {
+		return fmt.Errorf("invalid assignment %q, expected key=value", assignment)
+	}
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("invalid key %q in assignment %q", key, assignment)
+		}
+	}
+
+	var value any = raw
+	if !asString {
+		value = parseScalar(raw)
+	}
+
+	current := vars
+	for i, part := range parts[:len(parts)-1] {
+		next, exists := current[part]
+		if !exists || next == nil {
+			next = map[string]any{}
+			current[part] = next
+		}
+		m, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not a map", key, strings.Join(parts[:i+1], "."))
+		}
+		current = m
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+// decimalNumber matches numbers written in plain decimal notation, without leading zeros
+var decimalNumber = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// parseScalar converts a --set value to a boolean, number or null where YAML would, and leaves
+// anything else, including empty values and YAML collections, as a string.
+// Like Helm, only plain decimal numbers are converted: values with leading zeros and octal, hex or
+// exponent forms such as 0123, 0x1F and 1e3 stay strings, so image tags and IDs are kept as written.
+func parseScalar(raw string) any {
+	if raw == "" {
+		return ""
+	}
+	var value any
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+	switch value.(type) {
+	case nil, bool:
+		return value
+	case int, float64:
+		if decimalNumber.MatchString(raw) {
+			return value
+		}
+	}
+	return raw
+}
+
+// RenderManifest renders a manifest as a template with vars available as .Var, like in config
+// templates. name identifies the manifest in error messages.
+func (tr *TemplateRenderer) RenderManifest(name string, content []byte, vars map[string]any) ([]byte, error) {
+	rendered, err := tr.execute(name, string(content), &TemplateData{Var: vars}, renderContext{vars: vars})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return []byte(rendered), nil
+}
@@ -0,0 +1,257 @@
+package template
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeVariables(t *testing.T) {
+	common := map[string]any{
+		"team":    "platform",
+		"image":   map[string]any{"registry": "registry.example.com", "tag": "v1"},
+		"regions": []any{"ap-northeast-1", "us-east-1"},
+	}
+	env := map[string]any{
+		"image":   map[string]any{"tag": "v2"},
+		"regions": []any{"eu-west-1"},
+		"debug":   true,
+	}
+
+	result := MergeVariables(common, nil, env)
+
+	expected := map[string]any{
+		"team":    "platform",
+		"image":   map[string]any{"registry": "registry.example.com", "tag": "v2"},
+		"regions": []any{"eu-west-1"},
+		"debug":   true,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	// The layers must not be modified by the merge or by changes to the result
+	result["image"].(map[string]any)["tag"] = "changed"
+	if common["image"].(map[string]any)["tag"] != "v1" || env["image"].(map[string]any)["tag"] != "v2" {
+		t.Errorf("Expected layers to be unchanged, got %v and %v", common, env)
+	}
+
+	if result := MergeVariables(); len(result) != 0 {
+		t.Errorf("Expected empty map without layers, got %v", result)
+	}
+}
+
+func TestTemplateRenderer_RenderValue(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+	vars := map[string]any{"team": "data", "job": "backup", "replicas": 3}
+
+	testCases := []struct {
+		name          string
+		value         any
+		expected      any
+		errorContains string
+	}{
+		{name: "string", value: "{{ .team }}-{{ .job }}", expected: "data-backup"},
+		{name: "number variable", value: "{{ .replicas }}", expected: "3"},
+		{name: "string without actions", value: "plain", expected: "plain"},
+		{name: "non-string", value: 42, expected: 42},
+		{name: "nil", value: nil, expected: nil},
+		{
+			name:     "nested map and list",
+			value:    map[string]any{"name": "{{ .job }}", "args": []any{"--team={{ .team }}", 1}},
+			expected: map[string]any{"name": "backup", "args": []any{"--team=data", 1}},
+		},
+		{name: "missing variable", value: "{{ .missing }}", expected: "<no value>"},
+		{name: "parse error", value: "{{ .team", errorContains: "failed to render $.metadata.name: failed to parse template"},
+		{name: "execution error", value: "{{ .team.name }}", errorContains: "failed to execute template"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := renderer.RenderValue("$.metadata.name", tc.value, vars)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestSetVariable(t *testing.T) {
+	testCases := []struct {
+		name          string
+		vars          map[string]any
+		assignment    string
+		asString      bool
+		expected      map[string]any
+		errorContains string
+	}{
+		{
+			name:       "nested key creates maps",
+			vars:       map[string]any{},
+			assignment: "image.tag=v2",
+			expected:   map[string]any{"image": map[string]any{"tag": "v2"}},
+		},
+		{
+			name:       "overrides existing key and keeps siblings",
+			vars:       map[string]any{"image": map[string]any{"registry": "registry.example.com", "tag": "v1"}},
+			assignment: "image.tag=v2",
+			expected:   map[string]any{"image": map[string]any{"registry": "registry.example.com", "tag": "v2"}},
+		},
+		{
+			name:       "typed values",
+			vars:       map[string]any{},
+			assignment: "replicas=3",
+			expected:   map[string]any{"replicas": 3},
+		},
+		{
+			name:       "boolean",
+			vars:       map[string]any{},
+			assignment: "debug=true",
+			expected:   map[string]any{"debug": true},
+		},
+		{
+			name:       "null",
+			vars:       map[string]any{"debug": true},
+			assignment: "debug=null",
+			expected:   map[string]any{"debug": nil},
+		},
+		{
+			name:       "negative and fractional numbers",
+			vars:       map[string]any{},
+			assignment: "scale.factor=-1.5",
+			expected:   map[string]any{"scale": map[string]any{"factor": -1.5}},
+		},
+		{
+			name:       "leading zeros stay a string",
+			vars:       map[string]any{},
+			assignment: "image.tag=0123",
+			expected:   map[string]any{"image": map[string]any{"tag": "0123"}},
+		},
+		{
+			name:       "hex stays a string",
+			vars:       map[string]any{},
+			assignment: "color=0x1F",
+			expected:   map[string]any{"color": "0x1F"},
+		},
+		{
+			name:       "exponent stays a string",
+			vars:       map[string]any{},
+			assignment: "build.id=1e3",
+			expected:   map[string]any{"build": map[string]any{"id": "1e3"}},
+		},
+		{
+			name:       "zero",
+			vars:       map[string]any{},
+			assignment: "retries=0",
+			expected:   map[string]any{"retries": 0},
+		},
+		{
+			name:       "as string",
+			vars:       map[string]any{},
+			assignment: "build.id=0123",
+			asString:   true,
+			expected:   map[string]any{"build": map[string]any{"id": "0123"}},
+		},
+		{
+			name:       "value containing equals and commas",
+			vars:       map[string]any{},
+			assignment: "args=--a=1,--b=2",
+			expected:   map[string]any{"args": "--a=1,--b=2"},
+		},
+		{
+			name:       "collections stay strings",
+			vars:       map[string]any{},
+			assignment: "regions=[us-east-1]",
+			expected:   map[string]any{"regions": "[us-east-1]"},
+		},
+		{
+			name:       "empty value",
+			vars:       map[string]any{},
+			assignment: "suffix=",
+			expected:   map[string]any{"suffix": ""},
+		},
+		{
+			name:          "missing equals",
+			vars:          map[string]any{},
+			assignment:    "image.tag",
+			errorContains: `invalid assignment "image.tag", expected key=value`,
+		},
+		{
+			name:          "empty key part",
+			vars:          map[string]any{},
+			assignment:    "image..tag=v1",
+			errorContains: `invalid key "image..tag"`,
+		},
+		{
+			name:          "intermediate is not a map",
+			vars:          map[string]any{"image": "registry.example.com/app:v1"},
+			assignment:    "image.tag=v2",
+			errorContains: "cannot set image.tag: image is not a map",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := SetVariable(tc.vars, tc.assignment, tc.asString)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.vars, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, tc.vars)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_LoadValuesFiles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	renderer := New(logger)
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"common.yaml": "team: platform\nimage:\n  registry: registry.example.com\n  tag: v1\nregions: [ap-northeast-1, us-east-1]\n",
+		"region.yaml": "regions: [eu-west-1]\n",
+		"env.yaml":    "image:\n  tag: v2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(tempDir+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create values file: %v", err)
+		}
+	}
+
+	vars, err := renderer.LoadValuesFiles([]string{tempDir + "/common.yaml", tempDir + "/region.yaml", tempDir + "/env.yaml"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]any{
+		"team":    "platform",
+		"image":   map[string]any{"registry": "registry.example.com", "tag": "v2"},
+		"regions": []any{"eu-west-1"},
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, vars)
+	}
+
+	_, err = renderer.LoadValuesFiles([]string{tempDir + "/common.yaml", tempDir + "/missing.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("Expected error naming the missing values file, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reconcileNode は base (ベースマニフェスト) のノードツリーを、生成結果 result の内容に
+// 合わせて更新したノードを返します。値が変わっていないノードは base のものをそのまま使うため、
+// コメントやクォート・フロースタイルなどの書式が保持されます。
+// 値が変わったスカラーには base のコメントとスタイル (文字列同士の場合) を引き継ぎます。
+func reconcileNode(base, result *yaml.Node) *yaml.Node {
+	if base == nil {
+		return result
+	}
+
+	// ドキュメントノードはコメント (ファイル先頭のコメントなど) を保持したまま中身を突き合わせる
+	if base.Kind == yaml.DocumentNode {
+		inner := result
+		if result.Kind == yaml.DocumentNode {
+			if len(result.Content) == 0 {
+				return result
+			}
+			inner = result.Content[0]
+		}
+		if len(base.Content) == 0 {
+			return inner
+		}
+		doc := *base
+		doc.Content = []*yaml.Node{reconcileNode(base.Content[0], inner)}
+		return &doc
+	}
+
+	if base.Kind != result.Kind {
+		copyComments(result, base)
+		return result
+	}
+
+	switch base.Kind {
+	case yaml.MappingNode:
+		return reconcileMapping(base, result)
+	case yaml.SequenceNode:
+		return reconcileSequence(base, result)
+	case yaml.ScalarNode:
+		if sameScalar(base, result) {
+			return base
+		}
+		if base.ShortTag() == "!!str" && result.ShortTag() == "!!str" {
+			result.Style = base.Style
+		}
+		copyComments(result, base)
+		return result
+	default:
+		return result
+	}
+}
+
+// reconcileMapping はベースのキー順を保ちつつ、結果に存在しないキーを削除し、
+// 結果にのみ存在するキーを末尾に追加します
+func reconcileMapping(base, result *yaml.Node) *yaml.Node {
+	mapping := *base
+	mapping.Content = make([]*yaml.Node, 0, len(result.Content))
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key := base.Content[i]
+		resultValue := mappingValue(result, key.Value)
+		if resultValue == nil {
+			continue
+		}
+		seen[key.Value] = true
+		mapping.Content = append(mapping.Content, key, reconcileNode(base.Content[i+1], resultValue))
+	}
+
+	for i := 0; i+1 < len(result.Content); i += 2 {
+		if !seen[result.Content[i].Value] {
+			mapping.Content = append(mapping.Content, result.Content[i], result.Content[i+1])
+		}
+	}
+
+	return &mapping
+}
+
+// reconcileSequence は結果の要素順を保ちつつ、各要素を対応するベースの要素
+// (name が一致する要素、なければ同じインデックスの要素) と突き合わせます
+func reconcileSequence(base, result *yaml.Node) *yaml.Node {
+	sequence := *base
+	sequence.Content = make([]*yaml.Node, 0, len(result.Content))
+	for i, item := range result.Content {
+		sequence.Content = append(sequence.Content, reconcileNode(sequenceReferenceItem(base, item, i), item))
+	}
+	return &sequence
+}
+
+// sameScalar は2つのスカラーノードが同じ値を表すかどうかを判定します
+func sameScalar(a, b *yaml.Node) bool {
+	var av, bv any
+	if err := a.Decode(&av); err != nil {
+		return false
+	}
+	if err := b.Decode(&bv); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// copyComments は src のコメントを dst に引き継ぎます
+func copyComments(dst, src *yaml.Node) {
+	dst.HeadComment = src.HeadComment
+	dst.LineComment = src.LineComment
+	dst.FootComment = src.FootComment
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -74,6 +75,30 @@ func jsonFieldName(f reflect.StructField) string {
 	return tag
 }
 
+// KeyOrder は YAML 出力時のマップキーの並び順
+type KeyOrder string
+
+const (
+	// KeyOrderAlphabetical はすべてのキーをアルファベット順に並べます (デフォルト)
+	KeyOrderAlphabetical KeyOrder = "alphabetical"
+	// KeyOrderKubernetes は Kubernetes の慣例に従って並べます
+	// (apiVersion, kind, metadata, spec の順、リスト要素では name を先頭)
+	KeyOrderKubernetes KeyOrder = "kubernetes"
+	// KeyOrderReference は YAMLOptions.Reference に現れる順に並べます
+	KeyOrderReference KeyOrder = "reference"
+)
+
+// YAMLOptions は ToYAMLWithOptions の出力オプション
+type YAMLOptions struct {
+	Indent   int
+	KeyOrder KeyOrder
+	// Reference は KeyOrderReference で並び順の基準とするドキュメント (ベースマニフェストなど)
+	Reference *yaml.Node
+	// PreserveComments が true の場合、Reference のノードツリーを出力内容に合わせて更新して出力し、
+	// 変更のない部分のコメントや書式を保持します。キーの並び順は Reference に従います。
+	PreserveComments bool
+}
+
 // CleanCronWorkflow - YAML出力用の不要フィールドを除いたCronWorkflow表現
 type CleanCronWorkflow struct {
 	APIVersion string                                 `yaml:"apiVersion"`
@@ -119,6 +144,78 @@ func (c *CleanCronWorkflow) ToYAML() ([]byte, error) {
 
 // ToYAMLWithIndent はCleanCronWorkflowを指定されたインデントでYAMLバイト列に変換します
 func (c *CleanCronWorkflow) ToYAMLWithIndent(indent int) ([]byte, error) {
+	return c.ToYAMLWithOptions(YAMLOptions{Indent: indent})
+}
+
+// ToYAMLWithOptions はCleanCronWorkflowを指定されたオプションでYAMLバイト列に変換します
+func (c *CleanCronWorkflow) ToYAMLWithOptions(opts YAMLOptions) ([]byte, error) {
+	data, err := c.toMap()
+	if err != nil {
+		return nil, err
+	}
+
+	// yaml.v3 はマップのキーをアルファベット順に並べるため、Node に変換してから並び替える
+	var node yaml.Node
+	if err := node.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML node: %w", err)
+	}
+
+	if opts.PreserveComments {
+		if opts.Reference == nil {
+			return nil, fmt.Errorf("preserving comments requires a reference document")
+		}
+		node = *reconcileNode(opts.Reference, &node)
+	}
+
+	switch opts.KeyOrder {
+	case KeyOrderKubernetes:
+		orderKubernetes(&node, nil, false)
+	case KeyOrderReference:
+		if opts.Reference == nil {
+			return nil, fmt.Errorf("key order %q requires a reference document", opts.KeyOrder)
+		}
+		orderByReference(&node, opts.Reference)
+	}
+
+	// カスタムインデントでYAMLを生成
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(opts.Indent)
+
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML with custom indent: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close YAML encoder: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToJSON はCleanCronWorkflowをJSONバイト列に変換します（デフォルトは2スペースインデント）
+func (c *CleanCronWorkflow) ToJSON() ([]byte, error) {
+	return c.ToJSONWithIndent(2)
+}
+
+// ToJSONWithIndent はCleanCronWorkflowを指定されたインデントでJSONバイト列に変換します。
+// 空フィールドの除外やポインタ struct フィールドの保持は ToYAMLWithIndent と同一です。
+func (c *CleanCronWorkflow) ToJSONWithIndent(indent int) ([]byte, error) {
+	data, err := c.toMap()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(data, "", strings.Repeat(" ", indent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON with custom indent: %w", err)
+	}
+
+	return append(out, '\n'), nil
+}
+
+// toMap はCleanCronWorkflowを出力用のマップに変換します
+func (c *CleanCronWorkflow) toMap() (map[string]any, error) {
 	// カスタムマップを作成して正しいキー名にする
 	data := make(map[string]any)
 
@@ -165,20 +262,7 @@ func (c *CleanCronWorkflow) ToYAMLWithIndent(indent int) ([]byte, error) {
 		data["spec"] = cleanSpecMap
 	}
 
-	// カスタムインデントでYAMLを生成
-	var buf bytes.Buffer
-	encoder := yaml.NewEncoder(&buf)
-	encoder.SetIndent(indent)
-
-	if err := encoder.Encode(data); err != nil {
-		return nil, fmt.Errorf("failed to encode YAML with custom indent: %w", err)
-	}
-
-	if err := encoder.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close YAML encoder: %w", err)
-	}
-
-	return buf.Bytes(), nil
+	return data, nil
 }
 
 // removeEmptyFields は空のフィールドを再帰的に除外します。
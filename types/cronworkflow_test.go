@@ -1,11 +1,15 @@
 package types
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
 	argoworkflowsv1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -232,3 +236,286 @@ func TestPointerStructFieldNames_ContainsArchiveStrategies(t *testing.T) {
 		assert.True(t, ok, "pointerStructFieldNames should contain %q (auto-collected from argo CronWorkflowSpec)", name)
 	}
 }
+
+func TestCleanCronWorkflow_ToJSON(t *testing.T) {
+	cw := &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "json-workflow",
+		},
+		Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+			Schedule: "0 0 * * *",
+			WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+				Entrypoint: "main",
+				Templates: []argoworkflowsv1alpha1.Template{
+					{
+						Name: "main",
+						Outputs: argoworkflowsv1alpha1.Outputs{
+							Artifacts: []argoworkflowsv1alpha1.Artifact{
+								{
+									Name:    "result",
+									Path:    "/tmp/result",
+									Archive: &argoworkflowsv1alpha1.ArchiveStrategy{Tar: &argoworkflowsv1alpha1.TarStrategy{}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := NewCleanCronWorkflow(cw).ToJSON()
+	assert.NoError(t, err)
+
+	var parsed map[string]any
+	assert.NoError(t, json.Unmarshal(result, &parsed))
+
+	assert.Equal(t, "argoproj.io/v1alpha1", parsed["apiVersion"])
+	assert.Equal(t, "CronWorkflow", parsed["kind"])
+	assert.Equal(t, map[string]any{"name": "json-workflow"}, parsed["metadata"])
+
+	jsonString := string(result)
+	// Same cleaning rules as YAML: empty fields are removed, pointer struct fields are kept
+	assert.NotContains(t, jsonString, "creationTimestamp")
+	assert.NotContains(t, jsonString, "status")
+	assert.Contains(t, jsonString, `"tar": {}`)
+	// Default indent is 2 spaces
+	assert.Contains(t, jsonString, "\n  \"apiVersion\"")
+	assert.True(t, strings.HasSuffix(jsonString, "}\n"))
+}
+
+func TestCleanCronWorkflow_ToJSONWithIndent(t *testing.T) {
+	cw := &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+	}
+
+	result, err := NewCleanCronWorkflow(cw).ToJSONWithIndent(4)
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "\n    \"apiVersion\"")
+}
+
+func TestCleanCronWorkflow_ToYAMLWithOptions_KeyOrder(t *testing.T) {
+	cw := &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ordered",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "test"},
+		},
+		Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+			Schedule: "0 0 * * *",
+			WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+				Entrypoint: "main",
+				Templates: []argoworkflowsv1alpha1.Template{
+					{
+						Name:      "main",
+						Container: &corev1.Container{Image: "alpine", Command: []string{"echo"}},
+					},
+				},
+			},
+		},
+	}
+
+	reference := `kind: CronWorkflow
+apiVersion: argoproj.io/v1alpha1
+metadata:
+  namespace: default
+  name: ordered
+spec:
+  workflowSpec:
+    templates:
+    - name: main
+      container:
+        image: alpine
+        command: [echo]
+    entrypoint: main
+  schedule: "0 0 * * *"
+`
+	var referenceNode yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(reference), &referenceNode))
+
+	tests := []struct {
+		name     string
+		opts     YAMLOptions
+		expected string
+	}{
+		{
+			name: "alphabetical (default)",
+			opts: YAMLOptions{Indent: 2},
+			expected: `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  labels:
+    app: test
+  name: ordered
+  namespace: default
+spec:
+  schedule: 0 0 * * *
+  workflowSpec:
+    entrypoint: main
+    templates:
+      - container:
+          command:
+            - echo
+          image: alpine
+        name: main
+`,
+		},
+		{
+			name: "kubernetes",
+			opts: YAMLOptions{Indent: 2, KeyOrder: KeyOrderKubernetes},
+			expected: `apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: ordered
+  namespace: default
+  labels:
+    app: test
+spec:
+  schedule: 0 0 * * *
+  workflowSpec:
+    entrypoint: main
+    templates:
+      - name: main
+        container:
+          command:
+            - echo
+          image: alpine
+`,
+		},
+		{
+			name: "reference document, unknown keys last",
+			opts: YAMLOptions{Indent: 2, KeyOrder: KeyOrderReference, Reference: &referenceNode},
+			expected: `kind: CronWorkflow
+apiVersion: argoproj.io/v1alpha1
+metadata:
+  namespace: default
+  name: ordered
+  labels:
+    app: test
+spec:
+  workflowSpec:
+    templates:
+      - name: main
+        container:
+          image: alpine
+          command:
+            - echo
+    entrypoint: main
+  schedule: 0 0 * * *
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCleanCronWorkflow(cw).ToYAMLWithOptions(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(result))
+		})
+	}
+
+	t.Run("reference order without reference document", func(t *testing.T) {
+		_, err := NewCleanCronWorkflow(cw).ToYAMLWithOptions(YAMLOptions{Indent: 2, KeyOrder: KeyOrderReference})
+		assert.Error(t, err)
+	})
+}
+
+func TestCleanCronWorkflow_ToYAMLWithOptions_PreserveComments(t *testing.T) {
+	base := `# Runbook: https://example.com/runbook
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: base # overridden per value
+  namespace: default
+spec:
+  schedule: "0 0 * * *"
+  suspend: false
+  # keep retries low: the job is not idempotent
+  workflowSpec:
+    entrypoint: main
+    templates:
+    - name: main
+      container:
+        image: alpine # pinned by platform team
+        command: [echo]
+`
+	var baseNode yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(base), &baseNode))
+
+	cw := &argoworkflowsv1alpha1.CronWorkflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "CronWorkflow",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "renamed",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "test"},
+		},
+		Spec: argoworkflowsv1alpha1.CronWorkflowSpec{
+			Schedule: "0 0 * * *",
+			WorkflowSpec: argoworkflowsv1alpha1.WorkflowSpec{
+				Entrypoint: "main",
+				Templates: []argoworkflowsv1alpha1.Template{
+					{
+						Name:      "main",
+						Container: &corev1.Container{Image: "busybox", Command: []string{"echo"}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := NewCleanCronWorkflow(cw).ToYAMLWithOptions(YAMLOptions{
+		Indent:           2,
+		Reference:        &baseNode,
+		PreserveComments: true,
+	})
+	require.NoError(t, err)
+
+	expected := `# Runbook: https://example.com/runbook
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: renamed # overridden per value
+  namespace: default
+  labels:
+    app: test
+spec:
+  schedule: "0 0 * * *"
+  # keep retries low: the job is not idempotent
+  workflowSpec:
+    entrypoint: main
+    templates:
+      - name: main
+        container:
+          image: busybox # pinned by platform team
+          command: [echo]
+`
+	assert.Equal(t, expected, string(result))
+
+	// The reference document must not be modified, since it is shared between values
+	var again yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(base), &again))
+	original, err := yaml.Marshal(&again)
+	require.NoError(t, err)
+	reused, err := yaml.Marshal(&baseNode)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(reused))
+
+	t.Run("without reference document", func(t *testing.T) {
+		_, err := NewCleanCronWorkflow(cw).ToYAMLWithOptions(YAMLOptions{Indent: 2, PreserveComments: true})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubernetesRootKeys はルートマップで先頭に並べるキー
+var kubernetesRootKeys = []string{"apiVersion", "kind", "metadata", "spec", "status"}
+
+// kubernetesMetadataKeys は metadata マップで先頭に並べるキー
+var kubernetesMetadataKeys = []string{"name", "generateName", "namespace", "labels", "annotations"}
+
+// kubernetesListItemKeys はリスト要素のマップで先頭に並べるキー
+var kubernetesListItemKeys = []string{"name"}
+
+// orderKubernetes は Kubernetes の慣例に従ってマップのキーを並び替えます。
+// 優先キー以外はアルファベット順のまま残ります。
+func orderKubernetes(node *yaml.Node, path []string, listItem bool) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			orderKubernetes(child, path, false)
+		}
+	case yaml.MappingNode:
+		var priority []string
+		switch {
+		case len(path) == 0:
+			priority = kubernetesRootKeys
+		case len(path) == 1 && path[0] == "metadata":
+			priority = kubernetesMetadataKeys
+		case listItem:
+			priority = kubernetesListItemKeys
+		}
+		sortMappingNode(node, func(key string) int {
+			for i, k := range priority {
+				if k == key {
+					return i
+				}
+			}
+			return len(priority)
+		})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			orderKubernetes(node.Content[i+1], append(path, node.Content[i].Value), false)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			orderKubernetes(item, path, true)
+		}
+	}
+}
+
+// orderByReference はマップのキーを reference に現れる順に並び替えます。
+// reference に存在しないキーはその後ろにアルファベット順で並びます。
+// リスト要素は name が一致する要素、なければ同じインデックスの要素を基準にします。
+func orderByReference(node, reference *yaml.Node) {
+	if reference != nil && reference.Kind == yaml.DocumentNode && len(reference.Content) > 0 {
+		reference = reference.Content[0]
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			orderByReference(child, reference)
+		}
+	case yaml.MappingNode:
+		if reference == nil || reference.Kind != yaml.MappingNode {
+			return
+		}
+		rank := make(map[string]int)
+		for i := 0; i+1 < len(reference.Content); i += 2 {
+			rank[reference.Content[i].Value] = i / 2
+		}
+		sortMappingNode(node, func(key string) int {
+			if r, ok := rank[key]; ok {
+				return r
+			}
+			return len(rank)
+		})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			orderByReference(node.Content[i+1], mappingValue(reference, node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		if reference == nil || reference.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			orderByReference(item, sequenceReferenceItem(reference, item, i))
+		}
+	}
+}
+
+// sortMappingNode はマッピングノードのキー/値ペアを rank の昇順で安定ソートします
+func sortMappingNode(node *yaml.Node, rank func(key string) int) {
+	type pair struct {
+		key, value *yaml.Node
+	}
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return rank(pairs[a].key.Value) < rank(pairs[b].key.Value)
+	})
+	for i, p := range pairs {
+		node.Content[2*i] = p.key
+		node.Content[2*i+1] = p.value
+	}
+}
+
+// mappingValue はマッピングノードから key に対応する値ノードを返します
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceReferenceItem は item に対応する reference 内の要素を返します
+func sequenceReferenceItem(reference, item *yaml.Node, index int) *yaml.Node {
+	if name := mappingValue(item, "name"); name != nil {
+		for _, candidate := range reference.Content {
+			if candidateName := mappingValue(candidate, "name"); candidateName != nil && candidateName.Value == name.Value {
+				return candidate
+			}
+		}
+	}
+	if index < len(reference.Content) {
+		return reference.Content[index]
+	}
+	return nil
+}